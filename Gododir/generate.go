@@ -15,7 +15,7 @@ package dat
 {{ range $idx, $builder := .builders }}
 	// Interpolate interpolates this builders sql.
 	func (b *{{$builder}}) Interpolate() (string, []interface{}, error) {
-		return interpolate(b)
+		return interpolate(b.sqlDialect(), b)
 	}
 
 	// IsInterpolated determines if this builder will interpolate when
@@ -29,13 +29,46 @@ package dat
 		b.isInterpolated = enable
 		return b
 	}
+
+	// SetDialect sets the dialect this builder renders with. dat.Dialect is
+	// used if it is not set.
+	func (b *{{$builder}}) SetDialect(dialect SQLDialect) *{{$builder}} {
+		b.dialect = dialect
+		return b
+	}
+
+	// sqlDialect returns the dialect this builder renders with.
+	func (b *{{$builder}}) sqlDialect() SQLDialect {
+		if b.dialect != nil {
+			return b.dialect
+		}
+		return Dialect
+	}
+
+	// subquerySQL serializes this builder for use inside a statement written
+	// with d. A dialect set on this builder takes precedence.
+	func (b *{{$builder}}) subquerySQL(d SQLDialect) (string, []interface{}) {
+		if b.dialect != nil {
+			return b.ToSQL()
+		}
+		// write a copy, the builder may be shared
+		c := *b
+		{{- if eq $builder "SelectDocBuilder" }}
+		sb := *b.SelectBuilder
+		sb.dialect = d
+		c.SelectBuilder = &sb
+		{{- else }}
+		c.dialect = d
+		{{- end }}
+		return c.ToSQL()
+	}
 {{ end }}
 `
 
 func generateTasks(p *do.Project) {
 	p.Task("builder-boilerplate", nil, func(c *do.Context) {
 		context := do.M{
-			"builders": []string{"CallBuilder", "DeleteBuilder", "InsectBuilder",
+			"builders": []string{"CallBuilder", "CompoundBuilder", "DeleteBuilder", "InsectBuilder",
 				"InsertBuilder", "RawBuilder", "SelectBuilder", "SelectDocBuilder",
 				"UpdateBuilder", "UpsertBuilder"},
 		}
@@ -2,7 +2,9 @@ package dat
 
 // Builder interface is used to tie SQL generators to executors.
 type Builder interface {
-	// ToSQL builds the SQL and arguments from builder.
+	// ToSQL builds the SQL and arguments from builder. Builders with the
+	// same clauses build byte-identical SQL, columns of an Eq map or SetMap
+	// are written in sorted order.
 	ToSQL() (string, []interface{})
 
 	// Interpolate builds the interpolation SQL and arguments from builder.
@@ -14,6 +16,21 @@ type Builder interface {
 	IsInterpolated() bool
 }
 
+// subquerier is implemented by builders which can render with the dialect of
+// an enclosing statement.
+type subquerier interface {
+	subquerySQL(d SQLDialect) (string, []interface{})
+}
+
+// builderSQL serializes b for use inside a statement written with d. A
+// dialect set on b takes precedence.
+func builderSQL(d SQLDialect, b Builder) (string, []interface{}) {
+	if sub, ok := b.(subquerier); ok {
+		return sub.subquerySQL(d)
+	}
+	return b.ToSQL()
+}
+
 // Call creates a new CallBuilder for the given sproc and args.
 func Call(sproc string, args ...interface{}) *CallBuilder {
 	b := NewCallBuilder(sproc, args...)
@@ -63,6 +80,39 @@ func SQL(sql string, args ...interface{}) *RawBuilder {
 	return b
 }
 
+// Union creates a new CompoundBuilder combining the distinct rows of queries.
+func Union(builders ...Builder) *CompoundBuilder {
+	return compound("UNION", builders)
+}
+
+// UnionAll creates a new CompoundBuilder combining all rows of queries.
+func UnionAll(builders ...Builder) *CompoundBuilder {
+	return compound("UNION ALL", builders)
+}
+
+// Intersect creates a new CompoundBuilder returning the rows common to all
+// queries.
+func Intersect(builders ...Builder) *CompoundBuilder {
+	return compound("INTERSECT", builders)
+}
+
+// Except creates a new CompoundBuilder returning the rows of the first query
+// which are not in the others.
+func Except(builders ...Builder) *CompoundBuilder {
+	return compound("EXCEPT", builders)
+}
+
+// compound creates a new CompoundBuilder. It panics if there are fewer than 2
+// queries.
+func compound(operator string, builders []Builder) *CompoundBuilder {
+	b := NewCompoundBuilder(operator, builders...)
+	if b == nil {
+		panic(operator + " requires 2 or more queries")
+	}
+	b.Execer = nullExecer
+	return b
+}
+
 // Update creates a new UpdateBuilder for the given table.
 func Update(table string) *UpdateBuilder {
 	b := NewUpdateBuilder(table)
@@ -4,7 +4,7 @@ package dat
 
 // Interpolate interpolates this builders sql.
 func (b *CallBuilder) Interpolate() (string, []interface{}, error) {
-	return interpolate(b)
+	return interpolate(b.sqlDialect(), b)
 }
 
 // IsInterpolated determines if this builder will interpolate when
@@ -19,9 +19,80 @@ func (b *CallBuilder) SetIsInterpolated(enable bool) *CallBuilder {
 	return b
 }
 
+// SetDialect sets the dialect this builder renders with. dat.Dialect is
+// used if it is not set.
+func (b *CallBuilder) SetDialect(dialect SQLDialect) *CallBuilder {
+	b.dialect = dialect
+	return b
+}
+
+// sqlDialect returns the dialect this builder renders with.
+func (b *CallBuilder) sqlDialect() SQLDialect {
+	if b.dialect != nil {
+		return b.dialect
+	}
+	return Dialect
+}
+
+// subquerySQL serializes this builder for use inside a statement written
+// with d. A dialect set on this builder takes precedence.
+func (b *CallBuilder) subquerySQL(d SQLDialect) (string, []interface{}) {
+	if b.dialect != nil {
+		return b.ToSQL()
+	}
+	// write a copy, the builder may be shared
+	c := *b
+	c.dialect = d
+	return c.ToSQL()
+}
+
+// Interpolate interpolates this builders sql.
+func (b *CompoundBuilder) Interpolate() (string, []interface{}, error) {
+	return interpolate(b.sqlDialect(), b)
+}
+
+// IsInterpolated determines if this builder will interpolate when
+// Interpolate() is called.
+func (b *CompoundBuilder) IsInterpolated() bool {
+	return b.isInterpolated
+}
+
+// SetIsInterpolated sets whether this builder should interpolate.
+func (b *CompoundBuilder) SetIsInterpolated(enable bool) *CompoundBuilder {
+	b.isInterpolated = enable
+	return b
+}
+
+// SetDialect sets the dialect this builder renders with. dat.Dialect is
+// used if it is not set.
+func (b *CompoundBuilder) SetDialect(dialect SQLDialect) *CompoundBuilder {
+	b.dialect = dialect
+	return b
+}
+
+// sqlDialect returns the dialect this builder renders with.
+func (b *CompoundBuilder) sqlDialect() SQLDialect {
+	if b.dialect != nil {
+		return b.dialect
+	}
+	return Dialect
+}
+
+// subquerySQL serializes this builder for use inside a statement written
+// with d. A dialect set on this builder takes precedence.
+func (b *CompoundBuilder) subquerySQL(d SQLDialect) (string, []interface{}) {
+	if b.dialect != nil {
+		return b.ToSQL()
+	}
+	// write a copy, the builder may be shared
+	c := *b
+	c.dialect = d
+	return c.ToSQL()
+}
+
 // Interpolate interpolates this builders sql.
 func (b *DeleteBuilder) Interpolate() (string, []interface{}, error) {
-	return interpolate(b)
+	return interpolate(b.sqlDialect(), b)
 }
 
 // IsInterpolated determines if this builder will interpolate when
@@ -36,9 +107,36 @@ func (b *DeleteBuilder) SetIsInterpolated(enable bool) *DeleteBuilder {
 	return b
 }
 
+// SetDialect sets the dialect this builder renders with. dat.Dialect is
+// used if it is not set.
+func (b *DeleteBuilder) SetDialect(dialect SQLDialect) *DeleteBuilder {
+	b.dialect = dialect
+	return b
+}
+
+// sqlDialect returns the dialect this builder renders with.
+func (b *DeleteBuilder) sqlDialect() SQLDialect {
+	if b.dialect != nil {
+		return b.dialect
+	}
+	return Dialect
+}
+
+// subquerySQL serializes this builder for use inside a statement written
+// with d. A dialect set on this builder takes precedence.
+func (b *DeleteBuilder) subquerySQL(d SQLDialect) (string, []interface{}) {
+	if b.dialect != nil {
+		return b.ToSQL()
+	}
+	// write a copy, the builder may be shared
+	c := *b
+	c.dialect = d
+	return c.ToSQL()
+}
+
 // Interpolate interpolates this builders sql.
 func (b *InsectBuilder) Interpolate() (string, []interface{}, error) {
-	return interpolate(b)
+	return interpolate(b.sqlDialect(), b)
 }
 
 // IsInterpolated determines if this builder will interpolate when
@@ -53,9 +151,36 @@ func (b *InsectBuilder) SetIsInterpolated(enable bool) *InsectBuilder {
 	return b
 }
 
+// SetDialect sets the dialect this builder renders with. dat.Dialect is
+// used if it is not set.
+func (b *InsectBuilder) SetDialect(dialect SQLDialect) *InsectBuilder {
+	b.dialect = dialect
+	return b
+}
+
+// sqlDialect returns the dialect this builder renders with.
+func (b *InsectBuilder) sqlDialect() SQLDialect {
+	if b.dialect != nil {
+		return b.dialect
+	}
+	return Dialect
+}
+
+// subquerySQL serializes this builder for use inside a statement written
+// with d. A dialect set on this builder takes precedence.
+func (b *InsectBuilder) subquerySQL(d SQLDialect) (string, []interface{}) {
+	if b.dialect != nil {
+		return b.ToSQL()
+	}
+	// write a copy, the builder may be shared
+	c := *b
+	c.dialect = d
+	return c.ToSQL()
+}
+
 // Interpolate interpolates this builders sql.
 func (b *InsertBuilder) Interpolate() (string, []interface{}, error) {
-	return interpolate(b)
+	return interpolate(b.sqlDialect(), b)
 }
 
 // IsInterpolated determines if this builder will interpolate when
@@ -70,9 +195,36 @@ func (b *InsertBuilder) SetIsInterpolated(enable bool) *InsertBuilder {
 	return b
 }
 
+// SetDialect sets the dialect this builder renders with. dat.Dialect is
+// used if it is not set.
+func (b *InsertBuilder) SetDialect(dialect SQLDialect) *InsertBuilder {
+	b.dialect = dialect
+	return b
+}
+
+// sqlDialect returns the dialect this builder renders with.
+func (b *InsertBuilder) sqlDialect() SQLDialect {
+	if b.dialect != nil {
+		return b.dialect
+	}
+	return Dialect
+}
+
+// subquerySQL serializes this builder for use inside a statement written
+// with d. A dialect set on this builder takes precedence.
+func (b *InsertBuilder) subquerySQL(d SQLDialect) (string, []interface{}) {
+	if b.dialect != nil {
+		return b.ToSQL()
+	}
+	// write a copy, the builder may be shared
+	c := *b
+	c.dialect = d
+	return c.ToSQL()
+}
+
 // Interpolate interpolates this builders sql.
 func (b *RawBuilder) Interpolate() (string, []interface{}, error) {
-	return interpolate(b)
+	return interpolate(b.sqlDialect(), b)
 }
 
 // IsInterpolated determines if this builder will interpolate when
@@ -87,9 +239,36 @@ func (b *RawBuilder) SetIsInterpolated(enable bool) *RawBuilder {
 	return b
 }
 
+// SetDialect sets the dialect this builder renders with. dat.Dialect is
+// used if it is not set.
+func (b *RawBuilder) SetDialect(dialect SQLDialect) *RawBuilder {
+	b.dialect = dialect
+	return b
+}
+
+// sqlDialect returns the dialect this builder renders with.
+func (b *RawBuilder) sqlDialect() SQLDialect {
+	if b.dialect != nil {
+		return b.dialect
+	}
+	return Dialect
+}
+
+// subquerySQL serializes this builder for use inside a statement written
+// with d. A dialect set on this builder takes precedence.
+func (b *RawBuilder) subquerySQL(d SQLDialect) (string, []interface{}) {
+	if b.dialect != nil {
+		return b.ToSQL()
+	}
+	// write a copy, the builder may be shared
+	c := *b
+	c.dialect = d
+	return c.ToSQL()
+}
+
 // Interpolate interpolates this builders sql.
 func (b *SelectBuilder) Interpolate() (string, []interface{}, error) {
-	return interpolate(b)
+	return interpolate(b.sqlDialect(), b)
 }
 
 // IsInterpolated determines if this builder will interpolate when
@@ -104,9 +283,36 @@ func (b *SelectBuilder) SetIsInterpolated(enable bool) *SelectBuilder {
 	return b
 }
 
+// SetDialect sets the dialect this builder renders with. dat.Dialect is
+// used if it is not set.
+func (b *SelectBuilder) SetDialect(dialect SQLDialect) *SelectBuilder {
+	b.dialect = dialect
+	return b
+}
+
+// sqlDialect returns the dialect this builder renders with.
+func (b *SelectBuilder) sqlDialect() SQLDialect {
+	if b.dialect != nil {
+		return b.dialect
+	}
+	return Dialect
+}
+
+// subquerySQL serializes this builder for use inside a statement written
+// with d. A dialect set on this builder takes precedence.
+func (b *SelectBuilder) subquerySQL(d SQLDialect) (string, []interface{}) {
+	if b.dialect != nil {
+		return b.ToSQL()
+	}
+	// write a copy, the builder may be shared
+	c := *b
+	c.dialect = d
+	return c.ToSQL()
+}
+
 // Interpolate interpolates this builders sql.
 func (b *SelectDocBuilder) Interpolate() (string, []interface{}, error) {
-	return interpolate(b)
+	return interpolate(b.sqlDialect(), b)
 }
 
 // IsInterpolated determines if this builder will interpolate when
@@ -121,9 +327,38 @@ func (b *SelectDocBuilder) SetIsInterpolated(enable bool) *SelectDocBuilder {
 	return b
 }
 
+// SetDialect sets the dialect this builder renders with. dat.Dialect is
+// used if it is not set.
+func (b *SelectDocBuilder) SetDialect(dialect SQLDialect) *SelectDocBuilder {
+	b.dialect = dialect
+	return b
+}
+
+// sqlDialect returns the dialect this builder renders with.
+func (b *SelectDocBuilder) sqlDialect() SQLDialect {
+	if b.dialect != nil {
+		return b.dialect
+	}
+	return Dialect
+}
+
+// subquerySQL serializes this builder for use inside a statement written
+// with d. A dialect set on this builder takes precedence.
+func (b *SelectDocBuilder) subquerySQL(d SQLDialect) (string, []interface{}) {
+	if b.dialect != nil {
+		return b.ToSQL()
+	}
+	// write a copy, the builder may be shared
+	c := *b
+	sb := *b.SelectBuilder
+	sb.dialect = d
+	c.SelectBuilder = &sb
+	return c.ToSQL()
+}
+
 // Interpolate interpolates this builders sql.
 func (b *UpdateBuilder) Interpolate() (string, []interface{}, error) {
-	return interpolate(b)
+	return interpolate(b.sqlDialect(), b)
 }
 
 // IsInterpolated determines if this builder will interpolate when
@@ -138,9 +373,36 @@ func (b *UpdateBuilder) SetIsInterpolated(enable bool) *UpdateBuilder {
 	return b
 }
 
+// SetDialect sets the dialect this builder renders with. dat.Dialect is
+// used if it is not set.
+func (b *UpdateBuilder) SetDialect(dialect SQLDialect) *UpdateBuilder {
+	b.dialect = dialect
+	return b
+}
+
+// sqlDialect returns the dialect this builder renders with.
+func (b *UpdateBuilder) sqlDialect() SQLDialect {
+	if b.dialect != nil {
+		return b.dialect
+	}
+	return Dialect
+}
+
+// subquerySQL serializes this builder for use inside a statement written
+// with d. A dialect set on this builder takes precedence.
+func (b *UpdateBuilder) subquerySQL(d SQLDialect) (string, []interface{}) {
+	if b.dialect != nil {
+		return b.ToSQL()
+	}
+	// write a copy, the builder may be shared
+	c := *b
+	c.dialect = d
+	return c.ToSQL()
+}
+
 // Interpolate interpolates this builders sql.
 func (b *UpsertBuilder) Interpolate() (string, []interface{}, error) {
-	return interpolate(b)
+	return interpolate(b.sqlDialect(), b)
 }
 
 // IsInterpolated determines if this builder will interpolate when
@@ -154,3 +416,30 @@ func (b *UpsertBuilder) SetIsInterpolated(enable bool) *UpsertBuilder {
 	b.isInterpolated = enable
 	return b
 }
+
+// SetDialect sets the dialect this builder renders with. dat.Dialect is
+// used if it is not set.
+func (b *UpsertBuilder) SetDialect(dialect SQLDialect) *UpsertBuilder {
+	b.dialect = dialect
+	return b
+}
+
+// sqlDialect returns the dialect this builder renders with.
+func (b *UpsertBuilder) sqlDialect() SQLDialect {
+	if b.dialect != nil {
+		return b.dialect
+	}
+	return Dialect
+}
+
+// subquerySQL serializes this builder for use inside a statement written
+// with d. A dialect set on this builder takes precedence.
+func (b *UpsertBuilder) subquerySQL(d SQLDialect) (string, []interface{}) {
+	if b.dialect != nil {
+		return b.ToSQL()
+	}
+	// write a copy, the builder may be shared
+	c := *b
+	c.dialect = d
+	return c.ToSQL()
+}
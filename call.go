@@ -6,6 +6,7 @@ type CallBuilder struct {
 
 	args           []interface{}
 	isInterpolated bool
+	dialect        SQLDialect
 	sproc          string
 }
 
@@ -21,6 +22,7 @@ func NewCallBuilder(sproc string, args ...interface{}) *CallBuilder {
 // ToSQL serializes CallBuilder to a SQL string returning
 // valid SQL with placeholders an a slice of query arguments.
 func (b *CallBuilder) ToSQL() (string, []interface{}) {
+	d := b.sqlDialect()
 	buf := bufPool.Get()
 	defer bufPool.Put(buf)
 
@@ -29,7 +31,7 @@ func (b *CallBuilder) ToSQL() (string, []interface{}) {
 
 	length := len(b.args)
 	if length > 0 {
-		buildPlaceholders(buf, 1, length)
+		buildPlaceholders(d, buf, 1, length)
 		return buf.String(), b.args
 	}
 	buf.WriteString("()")
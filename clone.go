@@ -0,0 +1,92 @@
+package dat
+
+// cloneExecer returns the Execer of a clone b of a builder whose Execer is ex.
+func cloneExecer(ex Execer, b Builder) Execer {
+	if binder, ok := ex.(BuilderBinder); ok {
+		return binder.BindBuilder(b)
+	}
+	return ex
+}
+
+// uncache turns off caching for ex, if it is bound to a clone. The cache id
+// of the cloned builder would return its results.
+func uncache(ex Execer) {
+	if _, ok := ex.(BuilderBinder); ok {
+		ex.Cache("", 0, false)
+	}
+}
+
+// cloneBuilder returns a clone of a builder nested in another, or b if it
+// cannot be cloned.
+func cloneBuilder(b Builder) Builder {
+	switch t := b.(type) {
+	case *SelectBuilder:
+		return t.Clone()
+	case *SelectDocBuilder:
+		return t.Clone()
+	case *InsertBuilder:
+		return t.Clone()
+	case *UpdateBuilder:
+		return t.Clone()
+	case *DeleteBuilder:
+		return t.Clone()
+	case *UpsertBuilder:
+		return t.Clone()
+	case *InsectBuilder:
+		return t.Clone()
+	}
+	return b
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+// cloneFragments copies fragments, which are not modified once created.
+func cloneFragments(fragments []*whereFragment) []*whereFragment {
+	if fragments == nil {
+		return nil
+	}
+	return append([]*whereFragment{}, fragments...)
+}
+
+// cloneRows copies rows of values.
+func cloneRows(rows [][]interface{}) [][]interface{} {
+	if rows == nil {
+		return nil
+	}
+	clone := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		clone[i] = append([]interface{}{}, row...)
+	}
+	return clone
+}
+
+func cloneRecords(records []interface{}) []interface{} {
+	if records == nil {
+		return nil
+	}
+	return append([]interface{}{}, records...)
+}
+
+func cloneSetClauses(clauses []*setClause) []*setClause {
+	if clauses == nil {
+		return nil
+	}
+	return append([]*setClause{}, clauses...)
+}
+
+func (w withClause) clone() withClause {
+	clone := withClause{isRecursive: w.isRecursive}
+	for _, cte := range w.ctes {
+		c := *cte
+		if c.builder != nil {
+			c.builder = cloneBuilder(c.builder)
+		}
+		clone.ctes = append(clone.ctes, &c)
+	}
+	return clone
+}
@@ -0,0 +1,158 @@
+package dat
+
+import (
+	"testing"
+
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+func TestToSQLIdempotent(t *testing.T) {
+	sb := Select("p.*").
+		From("posts p").
+		Scope("INNER JOIN people u ON u.id = p.user_id WHERE u.name = $1", "mario").
+		Where("p.state = $1", "published")
+	sql, args := sb.ToSQL()
+	sql2, args2 := sb.ToSQL()
+	assert.Equal(t, sql, sql2)
+	assert.Equal(t, args, args2)
+	assert.Equal(t, stripWS(`
+		SELECT p.* FROM posts p INNER JOIN people u ON u.id = p.user_id
+		WHERE (p.state = $1) AND (u.name = $2)`), stripWS(sql))
+
+	sdb := SelectDoc("id").From("people p").Scope("WHERE p.id = $1", 1)
+	sql, args = sdb.ToSQL()
+	sql2, args2 = sdb.ToSQL()
+	assert.Equal(t, sql, sql2)
+	assert.Equal(t, args, args2)
+
+	ib := InsertInto("a").Blacklist("something_id").Record(someRecord{1, 88, false})
+	sql, args = ib.ToSQL()
+	sql2, args2 = ib.ToSQL()
+	assert.Equal(t, sql, sql2)
+	assert.Equal(t, args, args2)
+	assert.Equal(t, []string{"something_id"}, ib.cols)
+
+	ib = InsertInto("a").Whitelist("*").Record(someRecord{1, 88, false})
+	sql, _ = ib.ToSQL()
+	sql2, _ = ib.ToSQL()
+	assert.Equal(t, sql, sql2)
+	assert.Equal(t, []string{"*"}, ib.cols)
+
+	ub := Upsert("a").Whitelist("*").Record(someRecord{1, 88, false}).Key("something_id")
+	sql, _ = ub.ToSQL()
+	sql2, _ = ub.ToSQL()
+	assert.Equal(t, sql, sql2)
+	assert.Equal(t, []string{"*"}, ub.cols)
+
+	xb := Insect("a").Whitelist("*").Record(someRecord{1, 88, false})
+	sql, _ = xb.ToSQL()
+	sql2, _ = xb.ToSQL()
+	assert.Equal(t, sql, sql2)
+	assert.Equal(t, []string{"*"}, xb.cols)
+}
+
+func TestSelectClone(t *testing.T) {
+	base := Select("id").
+		From("posts").
+		Join("people", "u", "u.id = posts.user_id").
+		With("recent", Select("id").From("posts").Where("created_at > $1", 1)).
+		Where("state = $1", "published")
+	sql, args := base.ToSQL()
+
+	clone := base.Clone().Where("user_id = $1", 2).OrderBy("id").Limit(10)
+	cloneSQL, cloneArgs := clone.ToSQL()
+	assert.Equal(t, stripWS(`
+		WITH recent AS (SELECT id FROM posts WHERE (created_at > $1))
+		SELECT id FROM posts INNER JOIN people u ON u.id = posts.user_id
+		WHERE (state = $2) AND (user_id = $3)
+		ORDER BY id LIMIT 10`), stripWS(cloneSQL))
+	assert.Exactly(t, []interface{}{1, "published", 2}, cloneArgs)
+
+	sql2, args2 := base.ToSQL()
+	assert.Equal(t, sql, sql2)
+	assert.Equal(t, args, args2)
+}
+
+func TestSelectDocClone(t *testing.T) {
+	base := SelectDoc("id").From("people").Where("id > $1", 1)
+	sql, _ := base.ToSQL()
+
+	clone := base.Clone().Many("posts", "SELECT id FROM posts WHERE user_id = people.id").Where("name = $1", "mario")
+	cloneSQL, cloneArgs := clone.ToSQL()
+	assert.NotEqual(t, sql, cloneSQL)
+	assert.Exactly(t, []interface{}{1, "mario"}, cloneArgs)
+
+	sql2, _ := base.ToSQL()
+	assert.Equal(t, sql, sql2)
+}
+
+func TestInsertClone(t *testing.T) {
+	base := InsertInto("people").Columns("name", "email").Values("mario", "mario@acme.com")
+	sql, args := base.ToSQL()
+
+	clone := base.Clone().Values("luigi", "luigi@acme.com").OnConflict("email").DoNothing().Returning("id")
+	cloneSQL, cloneArgs := clone.ToSQL()
+	assert.Equal(t, stripWS(`
+		INSERT INTO people ("name","email") VALUES ($1,$2),($3,$4)
+		ON CONFLICT ("email") DO NOTHING RETURNING "id"`), stripWS(cloneSQL))
+	assert.Exactly(t, []interface{}{"mario", "mario@acme.com", "luigi", "luigi@acme.com"}, cloneArgs)
+
+	sql2, args2 := base.ToSQL()
+	assert.Equal(t, sql, sql2)
+	assert.Equal(t, args, args2)
+}
+
+func TestUpdateDeleteClone(t *testing.T) {
+	ub := Update("people").Set("name", "mario").Where("id = $1", 1)
+	sql, _ := ub.ToSQL()
+	cloneSQL, _ := ub.Clone().Set("email", "m@acme.com").Returning("id").ToSQL()
+	assert.NotEqual(t, sql, cloneSQL)
+	sql2, _ := ub.ToSQL()
+	assert.Equal(t, sql, sql2)
+
+	db := DeleteFrom("people").Where("id = $1", 1)
+	sql, _ = db.ToSQL()
+	cloneSQL, _ = db.Clone().Where("name = $1", "mario").ToSQL()
+	assert.NotEqual(t, sql, cloneSQL)
+	sql2, _ = db.ToSQL()
+	assert.Equal(t, sql, sql2)
+}
+
+func TestUpsertInsectClone(t *testing.T) {
+	ub := Upsert("people").Columns("name", "email").Key("email").Values("mario", "m@acme.com")
+	sql, args := ub.ToSQL()
+	_, cloneArgs := ub.Clone().Values("luigi", "l@acme.com").ToSQL()
+	assert.Equal(t, 2*len(args), len(cloneArgs))
+	sql2, _ := ub.ToSQL()
+	assert.Equal(t, sql, sql2)
+
+	xb := Insect("people").Columns("name", "email").Key("email").Values("mario", "m@acme.com")
+	sql, args = xb.ToSQL()
+	_, cloneArgs = xb.Clone().Values("luigi", "l@acme.com").ToSQL()
+	assert.NotEqual(t, len(args), len(cloneArgs))
+	sql2, _ = xb.ToSQL()
+	assert.Equal(t, sql, sql2)
+}
+
+type bindingExecer struct {
+	panicExecer
+	builder Builder
+}
+
+func (ex *bindingExecer) BindBuilder(b Builder) Execer {
+	return &bindingExecer{builder: b}
+}
+
+func TestCloneBindsExecer(t *testing.T) {
+	b := Select("id").From("people")
+	b.Execer = &bindingExecer{builder: b}
+
+	clone := b.Clone()
+	assert.True(t, clone.Execer.(*bindingExecer).builder == clone)
+	assert.True(t, b.Execer.(*bindingExecer).builder == b)
+
+	doc := SelectDoc("id").From("people")
+	doc.Execer = &bindingExecer{builder: doc}
+	docClone := doc.Clone()
+	assert.True(t, docClone.Execer.(*bindingExecer).builder == docClone)
+}
@@ -0,0 +1,16 @@
+package common
+
+// PlaceholderStyle is how a dialect writes bind parameters.
+type PlaceholderStyle int
+
+const (
+	// DollarPlaceholder writes ordinal placeholders $1, $2 ... (Postgres).
+	DollarPlaceholder PlaceholderStyle = iota
+	// QuestionPlaceholder writes positional ? placeholders (MySQL, SQLite).
+	// An arg referenced more than once is bound once per reference.
+	QuestionPlaceholder
+	// ColonPlaceholder writes named placeholders :p1, :p2 ... (Oracle).
+	ColonPlaceholder
+	// AtPlaceholder writes named placeholders @p1, @p2 ... (SQL Server).
+	AtPlaceholder
+)
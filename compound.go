@@ -0,0 +1,114 @@
+package dat
+
+// CompoundBuilder combines the results of queries with UNION, INTERSECT or
+// EXCEPT.
+//
+//	dat.UnionAll(
+//		dat.Select("id", "title").From("posts").Where("user_id = $1", 1),
+//		dat.Select("id", "title").From("drafts").Where("user_id = $1", 1),
+//	).OrderBy("id DESC").Limit(10)
+type CompoundBuilder struct {
+	Execer
+
+	isInterpolated bool
+	dialect        SQLDialect
+	operator       string
+	builders       []Builder
+	orderBys       []*whereFragment
+	limitCount     uint64
+	limitValid     bool
+	offsetCount    uint64
+	offsetValid    bool
+}
+
+// NewCompoundBuilder creates a new CompoundBuilder combining builders with
+// operator, such as "UNION ALL".
+func NewCompoundBuilder(operator string, builders ...Builder) *CompoundBuilder {
+	if len(builders) < 2 {
+		logger.Error(operator + " requires 2 or more queries")
+		return nil
+	}
+	return &CompoundBuilder{operator: operator, builders: builders, isInterpolated: EnableInterpolation}
+}
+
+// OrderBy appends a column to ORDER the combined result by
+func (b *CompoundBuilder) OrderBy(whereSQLOrMap interface{}, args ...interface{}) *CompoundBuilder {
+	b.orderBys = append(b.orderBys, newWhereFragment(whereSQLOrMap, args))
+	return b
+}
+
+// Limit sets a limit for the combined result; overrides any existing LIMIT
+func (b *CompoundBuilder) Limit(limit uint64) *CompoundBuilder {
+	b.limitCount = limit
+	b.limitValid = true
+	return b
+}
+
+// Offset sets an offset for the combined result; overrides any existing OFFSET
+func (b *CompoundBuilder) Offset(offset uint64) *CompoundBuilder {
+	b.offsetCount = offset
+	b.offsetValid = true
+	return b
+}
+
+// ToSQL serialized the CompoundBuilder to a SQL string
+// It returns the string with placeholders and a slice of query arguments
+func (b *CompoundBuilder) ToSQL() (string, []interface{}) {
+	d := b.sqlDialect()
+	if len(b.builders) < 2 {
+		panic(b.operator + " requires 2 or more queries")
+	}
+
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+	var args []interface{}
+	var placeholderStartPos int64 = 1
+
+	for i, sub := range b.builders {
+		if i > 0 {
+			buf.WriteRune(' ')
+			buf.WriteString(b.operator)
+			buf.WriteRune(' ')
+		}
+
+		sql, subArgs := builderSQL(d, sub)
+		parens := needsParens(sub)
+		if parens {
+			buf.WriteRune('(')
+		}
+		// map relative $1, $2 placeholders to absolute
+		remapPlaceholders(d, buf, sql, subArgs, &args, &placeholderStartPos)
+		if parens {
+			buf.WriteRune(')')
+		}
+	}
+
+	if len(b.orderBys) > 0 {
+		buf.WriteString(" ORDER BY ")
+		writeCommaFragmentsToSQL(d, buf, b.orderBys, &args, &placeholderStartPos)
+	}
+
+	if b.limitValid {
+		buf.WriteString(" LIMIT ")
+		writeUint64(buf, b.limitCount)
+	}
+
+	if b.offsetValid {
+		buf.WriteString(" OFFSET ")
+		writeUint64(buf, b.offsetCount)
+	}
+
+	return buf.String(), args
+}
+
+// needsParens reports whether the query must be parenthesized to be an
+// operand of UNION, INTERSECT or EXCEPT. Plain SELECTs are not, since SQLite
+// does not accept parenthesized operands.
+func needsParens(b Builder) bool {
+	sb, ok := b.(*SelectBuilder)
+	if !ok {
+		return true
+	}
+	return len(sb.with.ctes) > 0 || len(sb.orderBys) > 0 || sb.limitValid ||
+		sb.offsetValid || len(sb.fors) > 0
+}
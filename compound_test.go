@@ -0,0 +1,76 @@
+package dat
+
+import (
+	"testing"
+
+	"gopkg.in/mgutz/dat.v1/sqlite"
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+func TestUnionAllToSQL(t *testing.T) {
+	sql, args := UnionAll(
+		Select("id", "title").From("posts").Where("user_id = $1", 1),
+		Select("id", "title").From("drafts").Where("user_id = $1 AND state = $2", 2, "open"),
+	).
+		OrderBy("id DESC").
+		Limit(10).
+		Offset(20).
+		ToSQL()
+
+	assert.Equal(t, "SELECT id, title FROM posts WHERE (user_id = $1) UNION ALL SELECT id, title FROM drafts WHERE (user_id = $2 AND state = $3) ORDER BY id DESC LIMIT 10 OFFSET 20", sql)
+	assert.Equal(t, []interface{}{1, 2, "open"}, args)
+}
+
+func TestCompoundOperators(t *testing.T) {
+	a := Select("id").From("a")
+	b := Select("id").From("b")
+
+	sql, _ := Union(a, b).ToSQL()
+	assert.Equal(t, "SELECT id FROM a UNION SELECT id FROM b", sql)
+	sql, _ = Intersect(a, b).ToSQL()
+	assert.Equal(t, "SELECT id FROM a INTERSECT SELECT id FROM b", sql)
+	sql, _ = Except(a, b).ToSQL()
+	assert.Equal(t, "SELECT id FROM a EXCEPT SELECT id FROM b", sql)
+}
+
+func TestCompoundParens(t *testing.T) {
+	sql, args := Union(
+		Select("id").From("a").OrderBy("id").Limit(5),
+		Except(Select("id").From("b"), SQL("SELECT id FROM c WHERE x = $1", 3)),
+		Select("id").From("d").Where("y = $1", 4),
+	).OrderBy("id = $1 DESC", 9).ToSQL()
+
+	assert.Equal(t, "(SELECT id FROM a ORDER BY id LIMIT 5) UNION (SELECT id FROM b EXCEPT (SELECT id FROM c WHERE x = $1)) UNION SELECT id FROM d WHERE (y = $2) ORDER BY id = $3 DESC", sql)
+	assert.Equal(t, []interface{}{3, 4, 9}, args)
+}
+
+func TestCompoundDialect(t *testing.T) {
+	sql, args := UnionAll(
+		Select("id").From("a").Where("x = $1 OR y = $1", 1),
+		Select("id").From("b").Where("x = $1", 2),
+	).SetDialect(sqlite.New()).ToSQL()
+
+	assert.Equal(t, "SELECT id FROM a WHERE (x = ? OR y = ?) UNION ALL SELECT id FROM b WHERE (x = ?)", sql)
+	assert.Equal(t, []interface{}{1, 1, 2}, args)
+}
+
+func TestCompoundInterpolate(t *testing.T) {
+	sql, args, err := Union(
+		Select("id").From("a").Where("x = $1", 1),
+		Select("id").From("b").Where("x = $1", "b"),
+	).SetIsInterpolated(true).Interpolate()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT id FROM a WHERE (x = 1) UNION SELECT id FROM b WHERE (x = 'b')", sql)
+	assert.Nil(t, args)
+}
+
+func TestCompoundRequiresQueries(t *testing.T) {
+	assert.Panics(t, func() {
+		Union(Select("a").From("b"))
+	})
+	assert.Panics(t, func() {
+		Except()
+	})
+	assert.Nil(t, NewCompoundBuilder("UNION"))
+}
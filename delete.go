@@ -1,12 +1,21 @@
 package dat
 
+import "gopkg.in/mgutz/dat.v1/common"
+
 // DeleteBuilder contains the clauses for a DELETE statement
 type DeleteBuilder struct {
 	Execer
 
+	with           withClause
 	table          string
+	usingFragments []*whereFragment
 	whereFragments []*whereFragment
+	orderBys       []string
+	limitCount     uint64
+	limitValid     bool
+	returnings     []string
 	isInterpolated bool
+	dialect        SQLDialect
 	scope          Scope
 }
 
@@ -19,6 +28,19 @@ func NewDeleteBuilder(table string) *DeleteBuilder {
 	return &DeleteBuilder{table: table, isInterpolated: EnableInterpolation}
 }
 
+// Clone returns a deep copy of the builder. Clauses added to the clone do
+// not change b, so a base query can be shared and specialized.
+func (b *DeleteBuilder) Clone() *DeleteBuilder {
+	clone := *b
+	clone.with = b.with.clone()
+	clone.usingFragments = cloneFragments(b.usingFragments)
+	clone.whereFragments = cloneFragments(b.whereFragments)
+	clone.orderBys = cloneStrings(b.orderBys)
+	clone.returnings = cloneStrings(b.returnings)
+	clone.Execer = cloneExecer(b.Execer, &clone)
+	return &clone
+}
+
 // ScopeMap uses a predefined scope in place of WHERE.
 func (b *DeleteBuilder) ScopeMap(mapScope *MapScope, m M) *DeleteBuilder {
 	b.scope = mapScope.mergeClone(m)
@@ -28,11 +50,36 @@ func (b *DeleteBuilder) ScopeMap(mapScope *MapScope, m M) *DeleteBuilder {
 // Scope uses a predefined scope in place of WHERE.
 func (b *DeleteBuilder) Scope(sql string, args ...interface{}) *DeleteBuilder {
 	b.scope = ScopeFunc(func(table string) (string, []interface{}) {
-		return escapeScopeTable(sql, table), args
+		return escapeScopeTable(b.sqlDialect(), sql, table), args
 	})
 	return b
 }
 
+// With adds a common table expression to the WITH clause of the statement.
+// sqlOrBuilder is a Builder or a SQL string with args.
+func (b *DeleteBuilder) With(name string, sqlOrBuilder interface{}, args ...interface{}) *DeleteBuilder {
+	b.with.add(name, false, sqlOrBuilder, args)
+	return b
+}
+
+// WithRecursive adds a common table expression which may reference itself
+// and marks the WITH clause RECURSIVE.
+func (b *DeleteBuilder) WithRecursive(name string, sqlOrBuilder interface{}, args ...interface{}) *DeleteBuilder {
+	b.with.add(name, true, sqlOrBuilder, args)
+	return b
+}
+
+// Using appends tables to the USING clause of the statement. Columns of the
+// tables may be used in Where.
+//
+//	DeleteFrom("sessions").
+//		Using("users u").
+//		Where("u.id = sessions.user_id AND u.disabled_at < $1", cutoff)
+func (b *DeleteBuilder) Using(tables string, args ...interface{}) *DeleteBuilder {
+	b.usingFragments = append(b.usingFragments, newWhereFragment(tables, args))
+	return b
+}
+
 // Where appends a WHERE clause to the statement whereSQLOrMap can be a
 // string or map. If it's a string, args wil replaces any places holders
 func (b *DeleteBuilder) Where(whereSQLOrMap interface{}, args ...interface{}) *DeleteBuilder {
@@ -40,9 +87,37 @@ func (b *DeleteBuilder) Where(whereSQLOrMap interface{}, args ...interface{}) *D
 	return b
 }
 
+// OrderBy appends a column to ORDER the statement by
+func (b *DeleteBuilder) OrderBy(ord string) *DeleteBuilder {
+	b.orderBys = append(b.orderBys, ord)
+	return b
+}
+
+// Limit sets a limit for the statement; overrides any existing LIMIT. Use
+// OrderBy and Limit to delete in batches.
+func (b *DeleteBuilder) Limit(limit uint64) *DeleteBuilder {
+	b.limitCount = limit
+	b.limitValid = true
+	return b
+}
+
+// Returning sets the columns for the RETURNING clause
+func (b *DeleteBuilder) Returning(columns ...string) *DeleteBuilder {
+	b.returnings = columns
+	return b
+}
+
+func (b *DeleteBuilder) checkDialect(d SQLDialect) error {
+	if len(b.usingFragments) > 0 && !d.SupportsDeleteUsing() && d.RowIDColumn() == "" {
+		return unsupported(msgDeleteUsingUnsupported)
+	}
+	return checkReturning(d, b.returnings)
+}
+
 // ToSQL serialized the DeleteBuilder to a SQL string
 // It returns the string with placeholders and a slice of query arguments
 func (b *DeleteBuilder) ToSQL() (string, []interface{}) {
+	d := b.sqlDialect()
 	if len(b.table) == 0 {
 		panic("no table specified")
 	}
@@ -51,22 +126,83 @@ func (b *DeleteBuilder) ToSQL() (string, []interface{}) {
 	defer bufPool.Put(buf)
 
 	var args []interface{}
+	var placeholderStartPos int64 = 1
 
+	b.with.writeSQL(d, buf, &args, &placeholderStartPos)
 	buf.WriteString("DELETE FROM ")
 	buf.WriteString(b.table)
 
-	var placeholderStartPos int64 = 1
+	rowID := ""
+	if len(b.orderBys) > 0 || b.limitValid || (len(b.usingFragments) > 0 && !d.SupportsDeleteUsing()) {
+		rowID = d.RowIDColumn()
+	}
+	if rowID == "" && len(b.usingFragments) > 0 && !d.SupportsDeleteUsing() {
+		panic(msgDeleteUsingUnsupported)
+	}
+
+	if rowID == "" {
+		b.writeUsing(d, buf, " USING ", &args, &placeholderStartPos)
+		b.writeConditions(d, buf, &args, &placeholderStartPos)
+		b.writeOrderLimit(buf)
+	} else {
+		// DELETE does not accept ORDER BY and LIMIT, or USING, the rows are
+		// selected through their row ID
+		//
+		//	DELETE FROM t WHERE ctid IN (SELECT ctid FROM t WHERE ... ORDER BY ... LIMIT n)
+		buf.WriteString(" WHERE ")
+		buf.WriteString(rowID)
+		buf.WriteString(" IN (SELECT ")
+		if len(b.usingFragments) > 0 {
+			buf.WriteString(b.table)
+			buf.WriteRune('.')
+		}
+		buf.WriteString(rowID)
+		buf.WriteString(" FROM ")
+		buf.WriteString(b.table)
+		b.writeUsing(d, buf, ", ", &args, &placeholderStartPos)
+		b.writeConditions(d, buf, &args, &placeholderStartPos)
+		b.writeOrderLimit(buf)
+		buf.WriteRune(')')
+	}
+
+	writeReturning(d, buf, b.returnings)
+
+	return buf.String(), args
+}
+
+func (b *DeleteBuilder) writeUsing(d SQLDialect, buf common.BufferWriter, prefix string, args *[]interface{}, pos *int64) {
+	if len(b.usingFragments) > 0 {
+		buf.WriteString(prefix)
+		writeCommaFragmentsToSQL(d, buf, b.usingFragments, args, pos)
+	}
+}
 
-	// Write WHERE clause if we have any fragments
+// writeConditions writes the WHERE clause or the scope.
+func (b *DeleteBuilder) writeConditions(d SQLDialect, buf common.BufferWriter, args *[]interface{}, pos *int64) {
 	if b.scope == nil {
 		if len(b.whereFragments) > 0 {
 			buf.WriteString(" WHERE ")
-			writeAndFragmentsToSQL(buf, b.whereFragments, &args, &placeholderStartPos)
+			writeAndFragmentsToSQL(d, buf, b.whereFragments, args, pos)
 		}
 	} else {
-		whereFragment := newWhereFragment(b.scope.ToSQL(b.table))
-		writeScopeCondition(buf, whereFragment, &args, &placeholderStartPos)
+		whereFragment := newWhereFragment(scopeToSQL(d, b.scope, b.table))
+		writeScopeCondition(d, buf, whereFragment, args, pos)
 	}
+}
 
-	return buf.String(), args
+func (b *DeleteBuilder) writeOrderLimit(buf common.BufferWriter) {
+	if len(b.orderBys) > 0 {
+		buf.WriteString(" ORDER BY ")
+		for i, s := range b.orderBys {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(s)
+		}
+	}
+
+	if b.limitValid {
+		buf.WriteString(" LIMIT ")
+		writeUint64(buf, b.limitCount)
+	}
 }
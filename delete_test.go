@@ -3,6 +3,8 @@ package dat
 import (
 	"testing"
 
+	"gopkg.in/mgutz/dat.v1/mysql"
+	"gopkg.in/mgutz/dat.v1/sqlite"
 	"gopkg.in/stretchr/testify.v1/assert"
 )
 
@@ -39,3 +41,91 @@ func TestDeleteWhereExprSql(t *testing.T) {
 	assert.Equal(t, sql, `DELETE FROM a WHERE (foo = $1) AND (id=$2)`)
 	assert.Exactly(t, args, []interface{}{"bar", 100})
 }
+
+func TestDeleteUsingToSql(t *testing.T) {
+	sql, args := DeleteFrom("sessions").
+		Using("users u").
+		Where("u.id = sessions.user_id AND u.disabled_at < $1", 100).
+		ToSQL()
+
+	assert.Equal(t, "DELETE FROM sessions USING users u WHERE (u.id = sessions.user_id AND u.disabled_at < $1)", sql)
+	assert.Equal(t, []interface{}{100}, args)
+
+	sql, args = DeleteFrom("posts").
+		Using("(SELECT id FROM people WHERE name = $1) p", "Mario").
+		Where("p.id = posts.user_id AND posts.state = $1", "draft").
+		ToSQL()
+
+	assert.Equal(t, "DELETE FROM posts USING (SELECT id FROM people WHERE name = $1) p WHERE (p.id = posts.user_id AND posts.state = $2)", sql)
+	assert.Equal(t, []interface{}{"Mario", "draft"}, args)
+}
+
+func TestDeleteUsingRowID(t *testing.T) {
+	sql, args := DeleteFrom("sessions").
+		Using("users u").
+		Where("u.id = sessions.user_id AND u.disabled_at < $1", 100).
+		SetDialect(sqlite.New()).
+		ToSQL()
+
+	assert.Equal(t, "DELETE FROM sessions WHERE rowid IN (SELECT sessions.rowid FROM sessions, users u WHERE (u.id = sessions.user_id AND u.disabled_at < ?))", sql)
+	assert.Equal(t, []interface{}{100}, args)
+}
+
+func TestDeleteUsingUnsupported(t *testing.T) {
+	b := DeleteFrom("sessions").
+		Using("users u").
+		Where("u.id = sessions.user_id").
+		SetDialect(mysql.New())
+	assert.Panics(t, func() {
+		b.ToSQL()
+	})
+	_, _, err := b.Interpolate()
+	assert.Equal(t, ErrInvalidOperation, err)
+}
+
+func TestDeleteReturningToSql(t *testing.T) {
+	sql, args := DeleteFrom("a").Where("id = $1", 1).Returning("id", "name").ToSQL()
+
+	assert.Equal(t, `DELETE FROM a WHERE (id = $1) RETURNING "id","name"`, sql)
+	assert.Equal(t, []interface{}{1}, args)
+
+	assert.Panics(t, func() {
+		DeleteFrom("a").Returning("id").SetDialect(mysql.New()).ToSQL()
+	})
+}
+
+func TestDeleteLimitToSql(t *testing.T) {
+	sql, args := DeleteFrom("jobs").
+		Where("done_at < $1", 100).
+		OrderBy("done_at").
+		Limit(10).
+		Returning("id").
+		ToSQL()
+
+	assert.Equal(t, `DELETE FROM jobs WHERE ctid IN (SELECT ctid FROM jobs WHERE (done_at < $1) ORDER BY done_at LIMIT 10) RETURNING "id"`, sql)
+	assert.Equal(t, []interface{}{100}, args)
+
+	sql, args = DeleteFrom("comments").
+		Using("posts").
+		Where("posts.id = comments.post_id AND posts.state = $1", "spam").
+		Limit(10).
+		ToSQL()
+
+	assert.Equal(t, `DELETE FROM comments WHERE ctid IN (SELECT comments.ctid FROM comments, posts WHERE (posts.id = comments.post_id AND posts.state = $1) LIMIT 10)`, sql)
+	assert.Equal(t, []interface{}{"spam"}, args)
+}
+
+func TestDeleteLimitDialectToSql(t *testing.T) {
+	sql, args := DeleteFrom("jobs").
+		Where("done_at < $1", 100).
+		OrderBy("done_at").
+		Limit(10).
+		SetDialect(mysql.New()).
+		ToSQL()
+
+	assert.Equal(t, "DELETE FROM jobs WHERE (done_at < ?) ORDER BY done_at LIMIT 10", sql)
+	assert.Equal(t, []interface{}{100}, args)
+
+	sql, _ = DeleteFrom("jobs").Limit(10).Returning("id").SetDialect(sqlite.New()).ToSQL()
+	assert.Equal(t, `DELETE FROM jobs WHERE rowid IN (SELECT rowid FROM jobs LIMIT 10) RETURNING "id"`, sql)
+}
@@ -17,4 +17,27 @@ type SQLDialect interface {
 	WriteIdentifier(buf common.BufferWriter, column string)
 	// WriteFormattedTime writes a time formatted for the database
 	WriteFormattedTime(buf common.BufferWriter, t time.Time)
+	// PlaceholderStyle returns how bind parameters are written and parsed.
+	PlaceholderStyle() common.PlaceholderStyle
+	// WriteBoolLiteral writes a boolean literal.
+	WriteBoolLiteral(buf common.BufferWriter, b bool)
+	// SupportsReturning reports whether INSERT, UPDATE and DELETE accept a
+	// RETURNING clause.
+	SupportsReturning() bool
+	// SupportsWritableCTE reports whether WITH accepts data-modifying
+	// statements. Upsert and Insect use INSERT ... ON CONFLICT otherwise.
+	SupportsWritableCTE() bool
+	// SupportsOnConflict reports whether INSERT accepts an ON CONFLICT
+	// clause. Upsert uses ON DUPLICATE KEY UPDATE otherwise.
+	SupportsOnConflict() bool
+	// SupportsUpdateFrom reports whether UPDATE accepts a FROM clause.
+	SupportsUpdateFrom() bool
+	// SupportsDeleteUsing reports whether DELETE accepts a USING clause.
+	// DELETE with Using selects the rows to delete through RowIDColumn
+	// otherwise.
+	SupportsDeleteUsing() bool
+	// RowIDColumn returns the system column which identifies a row. DELETE
+	// with ORDER BY or LIMIT selects the rows to delete through it. An empty
+	// string means DELETE accepts ORDER BY and LIMIT.
+	RowIDColumn() string
 }
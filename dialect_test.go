@@ -0,0 +1,36 @@
+package dat
+
+import (
+	"testing"
+
+	"gopkg.in/mgutz/dat.v1/mysql"
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+func TestBuilderDialect(t *testing.T) {
+	sql, args := Update("a").Set("b", 1).Where("c = $1", 2).SetDialect(mysql.New()).ToSQL()
+	assert.Equal(t, "UPDATE `a` SET `b` = ? WHERE (c = ?)", sql)
+	assert.Equal(t, []interface{}{1, 2}, args)
+
+	// global dialect is the fallback
+	sql, _ = Update("a").Set("b", 1).Where("c = $1", 2).ToSQL()
+	assert.Equal(t, `UPDATE "a" SET "b" = $1 WHERE (c = $2)`, sql)
+}
+
+func TestBuilderDialectInterpolate(t *testing.T) {
+	sql, args, err := Update("a").Set("b", true).Where("c = $1", "it's").
+		SetDialect(mysql.New()).
+		SetIsInterpolated(true).
+		Interpolate()
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE `a` SET `b` = TRUE WHERE (c = 'it\\'s')", sql)
+	assert.Nil(t, args)
+}
+
+func TestBuilderDialectScope(t *testing.T) {
+	sql, args := Update("a").Set("b", 1).ScopeMap(NewScope("WHERE :TABLE.c = :c", M{"c": 2}), nil).
+		SetDialect(mysql.New()).
+		ToSQL()
+	assert.Equal(t, "UPDATE `a` SET `b` = ? WHERE `a`.c = ?", sql)
+	assert.Equal(t, []interface{}{1, 2}, args)
+}
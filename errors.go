@@ -22,4 +22,6 @@ var (
 	// ErrInvalidOperation occurs when an invalid operation occurs like cancelling
 	// an operation without a procPID.
 	ErrInvalidOperation = errors.New("invalid operation")
+	// ErrInvalidCursor occurs when a pagination cursor cannot be decoded.
+	ErrInvalidCursor = errors.New("invalid cursor")
 )
@@ -1,6 +1,9 @@
 package dat
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Result serves the same purpose as sql.Result. Defining
 // it for the package avoids tight coupling with database/sql.
@@ -13,6 +16,7 @@ type Result struct {
 type Execer interface {
 	Cache(id string, ttl time.Duration, invalidate bool) Execer
 	Timeout(time.Duration) Execer
+	WithContext(ctx context.Context) Execer
 	Interpolate() (string, []interface{}, error)
 	Exec() (*Result, error)
 
@@ -24,6 +28,12 @@ type Execer interface {
 	QueryJSON() ([]byte, error)
 }
 
+// BuilderBinder is implemented by an Execer which executes a builder. The
+// Clone method of a builder binds a copy of its Execer to the clone.
+type BuilderBinder interface {
+	BindBuilder(b Builder) Execer
+}
+
 const panicExecerMsg = "dat builders are disconnected, use sqlx-runner package"
 
 var nullExecer = &panicExecer{}
@@ -42,6 +52,10 @@ func (nop *panicExecer) Timeout(time.Duration) Execer {
 	panic(panicExecerMsg)
 }
 
+func (nop *panicExecer) WithContext(ctx context.Context) Execer {
+	panic(panicExecerMsg)
+}
+
 // Exec panics when Exec is called.
 func (nop *panicExecer) Exec() (*Result, error) {
 	panic(panicExecerMsg)
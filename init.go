@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/mgutz/logxi"
+	"gopkg.in/mgutz/dat.v1/common"
 )
 
 var logger logxi.Logger
@@ -18,20 +19,21 @@ var EnableInterpolation = false
 // maxLookup is the max lookup index for predefined lookup tables
 const maxLookup = 100
 
-// atoiTab holds "0" => 0, "1" .. 1 ... "99" -> 99 to avoid using strconv.Atoi()
-var atoiTab = make(map[string]int, maxLookup)
+// numPlaceholderStyles is the number of common.PlaceholderStyle values
+const numPlaceholderStyles = int(common.AtPlaceholder) + 1
 
 // itoaTab holds [0]=="0", [1]=="1", ... [n]=="n". To avoid strconv.Itoa
 var itoaTab = make([]string, maxLookup)
 
-// placeholdersTab holds $0, $1 ... $n to avoid using  "$" + strconv.FormatInt()
-var placeholderTab = make([]string, maxLookup)
+// placeholdersTab holds $0, $1 ... $n for each placeholder style to avoid
+// using  "$" + strconv.FormatInt()
+var placeholderTab [numPlaceholderStyles][]string
 
 // equalsPlaceholderTab " = $1"
-var equalsPlaceholderTab = make([]string, maxLookup)
+var equalsPlaceholderTab [numPlaceholderStyles][]string
 
 // inPlaceholderTab " IN $1"
-var inPlaceholderTab = make([]string, maxLookup)
+var inPlaceholderTab [numPlaceholderStyles][]string
 
 var identifierTab = make([]string, maxLookup)
 
@@ -45,13 +47,20 @@ func init() {
 	// which should cover most queries. Anything over maxLookup defaults to
 	// using strconv.FormatInt.
 	for i := 0; i < maxLookup; i++ {
-		placeholderTab[i] = fmt.Sprintf("$%d", i)
-		inPlaceholderTab[i] = fmt.Sprintf(" IN $%d", i)
-		equalsPlaceholderTab[i] = fmt.Sprintf(" = $%d", i)
-		atoiTab[strconv.Itoa(i)] = i
 		itoaTab[i] = strconv.Itoa(i)
 		identifierTab[i] = fmt.Sprintf("dat%d", i)
 	}
+	for style := range placeholderTab {
+		placeholderTab[style] = make([]string, maxLookup)
+		inPlaceholderTab[style] = make([]string, maxLookup)
+		equalsPlaceholderTab[style] = make([]string, maxLookup)
+		for i := 0; i < maxLookup; i++ {
+			placeholder := formatPlaceholder(common.PlaceholderStyle(style), i)
+			placeholderTab[style][i] = placeholder
+			inPlaceholderTab[style][i] = " IN " + placeholder
+			equalsPlaceholderTab[style][i] = " = " + placeholder
+		}
+	}
 
 	logger = logxi.New("dat")
 }
@@ -18,15 +18,26 @@ import "reflect"
 //		Values("mario", "mario@acme.com").
 //		Where("id=$1", 1).
 //		Returning("id", "name", "email")
+//
+//	// Inserts each row unless there exists a record with the same email.
+//	// A row is returned for each input row in order.
+//	conn.Insect("people").
+//		Columns("name", "email").
+//		Key("email").
+//		Values("mario", "mario@acme.com").
+//		Values("luigi", "luigi@acme.com").
+//		Returning("id", "name", "email")
 type InsectBuilder struct {
 	Execer
 
 	isInterpolated bool
+	dialect        SQLDialect
 	table          string
 	cols           []string
 	isBlacklist    bool
-	vals           []interface{}
-	record         interface{}
+	keys           []string
+	vals           [][]interface{}
+	records        []interface{}
 	returnings     []string
 	whereFragments []*whereFragment
 }
@@ -40,6 +51,20 @@ func NewInsectBuilder(table string) *InsectBuilder {
 	return &InsectBuilder{table: table, isInterpolated: EnableInterpolation}
 }
 
+// Clone returns a deep copy of the builder. Clauses added to the clone do
+// not change b, so a base query can be shared and specialized.
+func (b *InsectBuilder) Clone() *InsectBuilder {
+	clone := *b
+	clone.cols = cloneStrings(b.cols)
+	clone.keys = cloneStrings(b.keys)
+	clone.vals = cloneRows(b.vals)
+	clone.records = cloneRecords(b.records)
+	clone.returnings = cloneStrings(b.returnings)
+	clone.whereFragments = cloneFragments(b.whereFragments)
+	clone.Execer = cloneExecer(b.Execer, &clone)
+	return &clone
+}
+
 // Columns appends columns to insert in the statement
 func (b *InsectBuilder) Columns(columns ...string) *InsectBuilder {
 	return b.Whitelist(columns...)
@@ -60,15 +85,36 @@ func (b *InsectBuilder) Whitelist(columns ...string) *InsectBuilder {
 	return b
 }
 
+// Key sets the columns which identify an existing row. Key is required for
+// multiple rows and cannot be used with Where. The key columns must be unique
+// in the table, dialects without writable CTEs require a unique index on
+// them.
+func (b *InsectBuilder) Key(columns ...string) *InsectBuilder {
+	b.keys = columns
+	return b
+}
+
 // Values appends a set of values to the statement
 func (b *InsectBuilder) Values(vals ...interface{}) *InsectBuilder {
-	b.vals = vals
+	b.vals = append(b.vals, vals)
 	return b
 }
 
 // Record pulls in values to match Columns from the record
 func (b *InsectBuilder) Record(record interface{}) *InsectBuilder {
-	b.record = record
+	b.records = append(b.records, record)
+	return b
+}
+
+// Records pulls in values to match Columns from each record of a slice
+func (b *InsectBuilder) Records(records interface{}) *InsectBuilder {
+	v := reflect.Indirect(reflect.ValueOf(records))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		panic("Records requires a slice of records")
+	}
+	for i := 0; i < v.Len(); i++ {
+		b.records = append(b.records, v.Index(i).Interface())
+	}
 	return b
 }
 
@@ -78,76 +124,113 @@ func (b *InsectBuilder) Returning(columns ...string) *InsectBuilder {
 	return b
 }
 
+func (b *InsectBuilder) checkDialect(d SQLDialect) error {
+	if !d.SupportsReturning() {
+		return unsupported(msgInsectUnsupported)
+	}
+	return nil
+}
+
 // ToSQL serialized the InsectBuilder to a SQL string
 // It returns the string with placeholders and a slice of query arguments
 func (b *InsectBuilder) ToSQL() (string, []interface{}) {
+	d := b.sqlDialect()
 	if len(b.table) == 0 {
 		panic("no table specified")
 	}
+	if !d.SupportsReturning() {
+		panic(msgInsectUnsupported)
+	}
 	lenCols := len(b.cols)
+	lenRecords := len(b.records)
 	if lenCols == 0 {
 		panic("no columns specified")
 	}
-	if len(b.vals) == 0 && b.record == nil {
+	if len(b.vals) == 0 && lenRecords == 0 {
 		panic("no values or records specified")
 	}
 
-	if b.record == nil && b.cols[0] == "*" {
+	if lenRecords == 0 && b.cols[0] == "*" {
 		panic(`"*" can only be used in conjunction with Record`)
 	}
-	if b.record == nil && b.isBlacklist {
+	if lenRecords == 0 && b.isBlacklist {
 		panic(`Blacklist can only be used in conjunction with Record`)
 	}
 
-	// reflect fields removing blacklisted columns
-	if b.record != nil && b.isBlacklist {
-		b.cols = reflectExcludeColumns(b.record, b.cols)
+	if lenRecords > 0 {
+		// resolve columns on a copy, ToSQL does not modify the builder
+		c := *b
+		c.cols = recordColumns(b.records[0], b.cols, b.isBlacklist)
+		b = &c
 	}
-	// reflect all fields
-	if b.record != nil && b.cols[0] == "*" {
-		b.cols = reflectColumns(b.record)
+
+	returnings := b.returnings
+	if len(returnings) == 0 {
+		returnings = b.cols
+	}
+
+	rows := make([][]interface{}, 0, len(b.vals)+lenRecords)
+	rows = append(rows, b.vals...)
+	for _, rec := range b.records {
+		ind := reflect.Indirect(reflect.ValueOf(rec))
+		vals, err := valuesFor(ind.Type(), ind, b.cols)
+		if err != nil {
+			panic(err.Error())
+		}
+		rows = append(rows, vals)
+	}
+
+	keys := b.keys
+	if len(b.whereFragments) > 0 {
+		if len(keys) > 0 {
+			panic("Key and Where cannot be used together")
+		}
+		if len(rows) > 1 {
+			panic("Where cannot be used with multiple rows, use Key")
+		}
+	} else if len(keys) == 0 && len(rows) > 1 {
+		panic("Key is required to insect multiple rows")
 	}
 
+	if len(keys) > 0 {
+		if !d.SupportsWritableCTE() {
+			return b.toKeyConflictSQL(rows, keys, returnings)
+		}
+		return b.toValuesSQL(rows, keys, returnings)
+	}
+
+	vals := rows[0]
+	whereFragments := b.whereFragments
 	whereAdded := false
 
-	// build where clause from columns and values
-	if len(b.whereFragments) == 0 && b.record == nil {
+	// build where clause from columns and values, which SQLite requires for
+	// records too
+	if len(whereFragments) == 0 && (lenRecords == 0 || !d.SupportsWritableCTE()) {
 		whereAdded = true
 		for i, column := range b.cols {
-			b.whereFragments = append(b.whereFragments, newWhereFragment(column+"=$1", b.vals[i:i+1]))
+			whereFragments = append(whereFragments, newWhereFragment(column+"=$1", vals[i:i+1]))
 		}
 	}
 
-	if len(b.returnings) == 0 {
-		b.returnings = b.cols
+	if !d.SupportsWritableCTE() {
+		return b.toNotExistsSQL(vals, whereFragments, returnings)
 	}
 
 	/*
-	   END GOAL:
-
-	   	   WITH sel AS (
-	   	       SELECT id, user_name, auth_id, auth_provider
-	   	       FROM users
-	   	       WHERE user_name = $1 and auth_id = $2 and auth_provider = $3
-	   	   ), ins AS (
-	   	       INSERT INTO users (user_name, auth_id, auth_provider)
-	   	       SELECT $1, $2, $3
-	   	       WHERE NOT EXISTS (SELECT 1 FROM sel)
-	   	       RETURNING id, user_name, auth_id, auth_provider
-	   	   )
-	   	   SELECT * FROM ins
-	   	   UNION ALL
-	   	   SELECT * FROM sel
+	   WITH sel AS (
+	       SELECT id, user_name, auth_id, auth_provider
+	       FROM users
+	       WHERE user_name = $1 and auth_id = $2 and auth_provider = $3
+	   ), ins AS (
+	       INSERT INTO users (user_name, auth_id, auth_provider)
+	       SELECT $1, $2, $3
+	       WHERE NOT EXISTS (SELECT 1 FROM sel)
+	       RETURNING id, user_name, auth_id, auth_provider
+	   )
+	   SELECT * FROM ins
+	   UNION ALL
+	   SELECT * FROM sel
 	*/
-	if b.record != nil {
-		ind := reflect.Indirect(reflect.ValueOf(b.record))
-		var err error
-		b.vals, err = valuesFor(ind.Type(), ind, b.cols)
-		if err != nil {
-			panic(err.Error())
-		}
-	}
-
 	buf := bufPool.Get()
 	defer bufPool.Put(buf)
 	var args []interface{}
@@ -155,35 +238,190 @@ func (b *InsectBuilder) ToSQL() (string, []interface{}) {
 
 	buf.WriteString("WITH sel AS (")
 
-	sb := NewSelectBuilder(b.returnings...).
-		From(b.table)
-	sb.whereFragments = b.whereFragments
+	sb := NewSelectBuilder(returnings...).
+		From(b.table).
+		SetDialect(d)
+	sb.whereFragments = whereFragments
 	selectSQL, args = sb.ToSQL()
 	buf.WriteString(selectSQL)
 
 	buf.WriteString("), ins AS (")
 
 	buf.WriteString(" INSERT INTO ")
-	writeIdentifier(buf, b.table)
+	writeIdentifier(d, buf, b.table)
 	buf.WriteString("(")
-	writeIdentifiers(buf, b.cols, ",")
+	writeIdentifiers(d, buf, b.cols, ",")
 	buf.WriteString(") SELECT ")
 
 	if whereAdded {
-		writePlaceholders(buf, len(args), ",", 1)
+		writeReusedPlaceholders(d, buf, &args, len(args), ",", 1)
 	} else {
-		writePlaceholders(buf, len(b.vals), ",", len(args)+1)
-		args = append(args, b.vals...)
+		writePlaceholders(d, buf, len(vals), ",", len(args)+1)
+		args = append(args, vals...)
 	}
 
-	buf.WriteString(" WHERE NOT EXISTS (SELECT 1 FROM sel) RETURNING ")
-	writeIdentifiers(buf, b.returnings, ",")
+	buf.WriteString(" WHERE NOT EXISTS (SELECT 1 FROM sel)")
+	writeReturning(d, buf, returnings)
 
 	buf.WriteString(") SELECT * FROM ins UNION ALL SELECT * FROM sel")
 
 	return buf.String(), args
 }
 
+// toValuesSQL writes the insect of multiple rows keyed on keys. Rows of
+// new_values without a matching row are inserted, once per key. Inserted and
+// existing rows are joined back to new_values, so a row is returned for each
+// input row in input order. Key columns must not be NULL.
+//
+//	WITH
+//		new_values AS (
+//			SELECT "name","email",0 AS _ord FROM "people" WHERE false
+//			UNION ALL SELECT $1,$2,1
+//			UNION ALL SELECT $3,$4,2
+//		),
+//		ins AS (
+//			INSERT INTO "people" ("name","email")
+//			SELECT DISTINCT ON ("email") "name","email" FROM new_values
+//			WHERE NOT EXISTS (SELECT 1 FROM "people" WHERE "people"."email" = new_values."email")
+//			ORDER BY "email",_ord
+//			RETURNING *
+//		)
+//	SELECT "r"."id","r"."name"
+//	FROM new_values JOIN (
+//		SELECT * FROM ins
+//		UNION ALL
+//		SELECT * FROM "people" WHERE EXISTS (SELECT 1 FROM new_values WHERE "people"."email" = new_values."email")
+//	) r ON "r"."email" = new_values."email"
+//	ORDER BY new_values._ord
+func (b *InsectBuilder) toValuesSQL(rows [][]interface{}, keys []string, returnings []string) (string, []interface{}) {
+	d := b.sqlDialect()
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+
+	var args []interface{}
+	buf.WriteString("WITH new_values AS (")
+	writeNewValues(d, buf, b.table, b.cols, rows, true, &args)
+
+	buf.WriteString("), ins AS (INSERT INTO ")
+	writeIdentifier(d, buf, b.table)
+	buf.WriteString(" (")
+	writeIdentifiers(d, buf, b.cols, ",")
+	buf.WriteString(") SELECT DISTINCT ON (")
+	writeIdentifiers(d, buf, keys, ",")
+	buf.WriteString(") ")
+	writeIdentifiers(d, buf, b.cols, ",")
+	buf.WriteString(" FROM new_values WHERE NOT EXISTS (SELECT 1 FROM ")
+	writeIdentifier(d, buf, b.table)
+	buf.WriteString(" WHERE ")
+	writeKeysMatch(d, buf, b.table, "new_values", keys)
+	buf.WriteString(") ORDER BY ")
+	writeIdentifiers(d, buf, keys, ",")
+	buf.WriteString(",_ord RETURNING *) SELECT ")
+	for i, col := range returnings {
+		if i > 0 {
+			buf.WriteRune(',')
+		}
+		writeIdentifier(d, buf, "r")
+		buf.WriteRune('.')
+		writeIdentifier(d, buf, col)
+	}
+
+	buf.WriteString(" FROM new_values JOIN (SELECT * FROM ins UNION ALL SELECT * FROM ")
+	writeIdentifier(d, buf, b.table)
+	buf.WriteString(" WHERE EXISTS (SELECT 1 FROM new_values WHERE ")
+	writeKeysMatch(d, buf, b.table, "new_values", keys)
+	buf.WriteString(")) r ON ")
+	writeKeysMatch(d, buf, "r", "new_values", keys)
+	buf.WriteString(" ORDER BY new_values._ord")
+
+	return buf.String(), args
+}
+
+// toNotExistsSQL writes the insect for SQLite, which has no writable CTEs.
+// The row is inserted unless a row matches the WHERE clause, then the
+// matching or inserted row is selected. These are two statements, which the
+// SQLite driver runs in order, returning the rows of the last.
+//
+//	INSERT INTO people (name, email)
+//	SELECT $1, $2
+//	WHERE NOT EXISTS (SELECT 1 FROM people WHERE name = $3 AND email = $4);
+//	SELECT id, name, email FROM people
+//	WHERE (name = $5 AND email = $6)
+//	OR (changes() > 0 AND rowid = last_insert_rowid())
+func (b *InsectBuilder) toNotExistsSQL(vals []interface{}, whereFragments []*whereFragment, returnings []string) (string, []interface{}) {
+	d := b.sqlDialect()
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+
+	args := make([]interface{}, len(vals))
+	copy(args, vals)
+
+	buf.WriteString("INSERT INTO ")
+	writeIdentifier(d, buf, b.table)
+	buf.WriteString(" (")
+	writeIdentifiers(d, buf, b.cols, ",")
+	buf.WriteString(") SELECT ")
+	writePlaceholders(d, buf, len(vals), ",", 1)
+
+	pos := int64(len(args) + 1)
+	buf.WriteString(" WHERE NOT EXISTS (SELECT 1 FROM ")
+	writeIdentifier(d, buf, b.table)
+	buf.WriteString(" WHERE ")
+	writeAndFragmentsToSQL(d, buf, whereFragments, &args, &pos)
+
+	buf.WriteString("); SELECT ")
+	writeIdentifiers(d, buf, returnings, ",")
+	buf.WriteString(" FROM ")
+	writeIdentifier(d, buf, b.table)
+	buf.WriteString(" WHERE (")
+	writeAndFragmentsToSQL(d, buf, whereFragments, &args, &pos)
+	buf.WriteString(") OR (changes() > 0 AND rowid = last_insert_rowid())")
+
+	return buf.String(), args
+}
+
+// toKeyConflictSQL writes the insect of multiple rows for dialects without
+// writable CTEs. The key columns are the conflict target and must have a
+// unique index. The no-op update of a key column returns the existing row.
+//
+//	INSERT INTO people (name, email)
+//	VALUES ($1, $2), ($3, $4)
+//	ON CONFLICT (email) DO UPDATE
+//	SET email = EXCLUDED.email
+//	RETURNING id, name, email
+func (b *InsectBuilder) toKeyConflictSQL(rows [][]interface{}, keys []string, returnings []string) (string, []interface{}) {
+	d := b.sqlDialect()
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+
+	var args []interface{}
+
+	buf.WriteString("INSERT INTO ")
+	writeIdentifier(d, buf, b.table)
+	buf.WriteString(" (")
+	writeIdentifiers(d, buf, b.cols, ",")
+	buf.WriteString(") VALUES ")
+	start := 1
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteRune(',')
+		}
+		buildPlaceholders(d, buf, start, len(row))
+		args = append(args, row...)
+		start += len(row)
+	}
+	pos := int64(start)
+
+	conflict := &conflictClause{
+		columns:    keys,
+		setClauses: []*setClause{{column: keys[0], value: excluded(keys[0])}},
+	}
+	conflict.writeSQL(d, buf, &args, &pos)
+	writeReturning(d, buf, returnings)
+
+	return buf.String(), args
+}
+
 // Where appends a WHERE clause to the statement for the given string and args
 // or map of column/value pairs
 func (b *InsectBuilder) Where(whereSQLOrMap interface{}, args ...interface{}) *InsectBuilder {
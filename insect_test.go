@@ -4,6 +4,9 @@ import (
 	"regexp"
 	"testing"
 
+	"gopkg.in/mgutz/dat.v1/mysql"
+	"gopkg.in/mgutz/dat.v1/postgres"
+	"gopkg.in/mgutz/dat.v1/sqlite"
 	"gopkg.in/stretchr/testify.v1/assert"
 )
 
@@ -107,3 +110,124 @@ func TestInsectSqlRecord(t *testing.T) {
 	assert.Equal(t, stripWS(expected), stripWS(sql))
 	assert.Equal(t, args, []interface{}{3, 1, 2, 4})
 }
+
+func TestInsectSQLConflict(t *testing.T) {
+	Dialect = sqlite.New()
+	defer func() {
+		Dialect = postgres.New()
+	}()
+
+	sql, args := Insect("tab").Columns("b", "c").Values(1, 2).Returning("id").ToSQL()
+	expected := `
+	INSERT INTO "tab" ("b","c")
+	SELECT ?,?
+	WHERE NOT EXISTS (SELECT 1 FROM "tab" WHERE (b=?) AND (c=?));
+	SELECT "id" FROM "tab"
+	WHERE ((b=?) AND (c=?))
+	OR (changes() > 0 AND rowid = last_insert_rowid())
+	`
+
+	assert.Equal(t, stripWS(expected), stripWS(sql))
+	assert.Equal(t, []interface{}{1, 2, 1, 2, 1, 2}, args)
+}
+
+func TestInsectSQLMultiple(t *testing.T) {
+	type rec struct {
+		B int `db:"b"`
+		C int `db:"c"`
+	}
+
+	sql, args := Insect("tab").
+		Columns("b", "c").
+		Key("b").
+		Values(1, 2).
+		Records([]*rec{{3, 4}}).
+		Returning("id", "c").
+		ToSQL()
+
+	expected := `
+	WITH
+		new_values AS (
+			SELECT "b","c",0 AS _ord FROM "tab" WHERE false
+			UNION ALL SELECT $1,$2,1
+			UNION ALL SELECT $3,$4,2
+		), ins AS (
+			INSERT INTO "tab" ("b","c")
+			SELECT DISTINCT ON ("b") "b","c" FROM new_values
+			WHERE NOT EXISTS (SELECT 1 FROM "tab" WHERE "tab"."b" = new_values."b")
+			ORDER BY "b",_ord
+			RETURNING *
+		)
+	SELECT "r"."id","r"."c"
+	FROM new_values JOIN (
+		SELECT * FROM ins
+		UNION ALL
+		SELECT * FROM "tab" WHERE EXISTS (SELECT 1 FROM new_values WHERE "tab"."b" = new_values."b")
+	) r ON "r"."b" = new_values."b"
+	ORDER BY new_values._ord
+	`
+	assert.Equal(t, stripWS(expected), stripWS(sql))
+	assert.Equal(t, []interface{}{1, 2, 3, 4}, args)
+}
+
+func TestInsectSQLMultipleKeyRequired(t *testing.T) {
+	assert.Panics(t, func() {
+		Insect("tab").Columns("b", "c").Values(1, 2).Values(3, 4).ToSQL()
+	})
+	assert.Panics(t, func() {
+		Insect("tab").Columns("b", "c").Values(1, 2).Values(3, 4).Where("d = $1", 3).ToSQL()
+	})
+	assert.Panics(t, func() {
+		Insect("tab").Columns("b", "c").Values(1, 2).Key("b").Where("d = $1", 3).ToSQL()
+	})
+}
+
+func TestInsectSQLMultipleConflict(t *testing.T) {
+	sql, args := Insect("tab").
+		Columns("b", "c").
+		Key("b").
+		Values(1, 2).
+		Values(3, 4).
+		Returning("id").
+		SetDialect(sqlite.New()).
+		ToSQL()
+	expected := `
+	INSERT INTO "tab" ("b","c")
+	VALUES (?,?),(?,?)
+	ON CONFLICT ("b") DO UPDATE
+	SET "b" = EXCLUDED."b"
+	RETURNING "id"
+	`
+
+	assert.Equal(t, stripWS(expected), stripWS(sql))
+	assert.Equal(t, []interface{}{1, 2, 3, 4}, args)
+}
+
+func TestInsectSQLConflictWhere(t *testing.T) {
+	sql, args := Insect("tab").
+		Columns("b", "c").
+		Values(1, 2).
+		Where("d = $1", 3).
+		SetDialect(sqlite.New()).
+		ToSQL()
+	expected := `
+	INSERT INTO "tab" ("b","c")
+	SELECT ?,?
+	WHERE NOT EXISTS (SELECT 1 FROM "tab" WHERE (d = ?));
+	SELECT "b","c" FROM "tab"
+	WHERE ((d = ?))
+	OR (changes() > 0 AND rowid = last_insert_rowid())
+	`
+
+	assert.Equal(t, stripWS(expected), stripWS(sql))
+	assert.Equal(t, []interface{}{1, 2, 3, 3}, args)
+}
+
+func TestInsectSQLUnsupported(t *testing.T) {
+	b := Insect("tab").Columns("b", "c").Values(1, 2).SetDialect(mysql.New())
+	assert.Panics(t, func() {
+		b.ToSQL()
+	})
+	_, _, err := b.Interpolate()
+	assert.Equal(t, ErrInvalidOperation, err)
+}
@@ -3,6 +3,8 @@ package dat
 import (
 	"bytes"
 	"reflect"
+
+	"gopkg.in/mgutz/dat.v1/common"
 )
 
 // InsertBuilder contains the clauses for an INSERT statement
@@ -10,12 +12,25 @@ type InsertBuilder struct {
 	Execer
 
 	isInterpolated bool
+	dialect        SQLDialect
+	with           withClause
 	table          string
 	cols           []string
 	isBlacklist    bool
 	vals           [][]interface{}
 	records        []interface{}
+	fromSelect     *SelectBuilder
 	returnings     []string
+	conflict       *conflictClause
+}
+
+// conflictClause contains the ON CONFLICT clause of an INSERT statement
+type conflictClause struct {
+	columns        []string
+	constraint     string
+	doNothing      bool
+	setClauses     []*setClause
+	whereFragments []*whereFragment
 }
 
 // NewInsertBuilder creates a new InsertBuilder for the given table.
@@ -27,6 +42,43 @@ func NewInsertBuilder(table string) *InsertBuilder {
 	return &InsertBuilder{table: table, isInterpolated: EnableInterpolation}
 }
 
+// Clone returns a deep copy of the builder. Clauses added to the clone do
+// not change b, so a base query can be shared and specialized.
+func (b *InsertBuilder) Clone() *InsertBuilder {
+	clone := *b
+	clone.with = b.with.clone()
+	clone.cols = cloneStrings(b.cols)
+	clone.vals = cloneRows(b.vals)
+	clone.records = cloneRecords(b.records)
+	if b.fromSelect != nil {
+		clone.fromSelect = b.fromSelect.Clone()
+	}
+	clone.returnings = cloneStrings(b.returnings)
+	if b.conflict != nil {
+		conflict := *b.conflict
+		conflict.columns = cloneStrings(b.conflict.columns)
+		conflict.setClauses = cloneSetClauses(b.conflict.setClauses)
+		conflict.whereFragments = cloneFragments(b.conflict.whereFragments)
+		clone.conflict = &conflict
+	}
+	clone.Execer = cloneExecer(b.Execer, &clone)
+	return &clone
+}
+
+// With adds a common table expression to the WITH clause of the statement.
+// sqlOrBuilder is a Builder or a SQL string with args.
+func (b *InsertBuilder) With(name string, sqlOrBuilder interface{}, args ...interface{}) *InsertBuilder {
+	b.with.add(name, false, sqlOrBuilder, args)
+	return b
+}
+
+// WithRecursive adds a common table expression which may reference itself
+// and marks the WITH clause RECURSIVE.
+func (b *InsertBuilder) WithRecursive(name string, sqlOrBuilder interface{}, args ...interface{}) *InsertBuilder {
+	b.with.add(name, true, sqlOrBuilder, args)
+	return b
+}
+
 // Columns appends columns to insert in the statement
 func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
 	return b.Whitelist(columns...)
@@ -59,12 +111,84 @@ func (b *InsertBuilder) Record(record interface{}) *InsertBuilder {
 	return b
 }
 
+// FromSelect inserts the rows of a SELECT statement instead of values. The
+// query's columns must match Columns.
+func (b *InsertBuilder) FromSelect(sb *SelectBuilder) *InsertBuilder {
+	b.fromSelect = sb
+	return b
+}
+
 // Returning sets the columns for the RETURNING clause
 func (b *InsertBuilder) Returning(columns ...string) *InsertBuilder {
 	b.returnings = columns
 	return b
 }
 
+func (b *InsertBuilder) checkDialect(d SQLDialect) error {
+	if b.conflict != nil && !d.SupportsOnConflict() {
+		return unsupported(msgOnConflictUnsupported)
+	}
+	return checkReturning(d, b.returnings)
+}
+
+// OnConflict adds an ON CONFLICT clause with the columns of a unique index as
+// the conflict target. The target may be omitted for DO NOTHING.
+func (b *InsertBuilder) OnConflict(columns ...string) *InsertBuilder {
+	b.conflict = &conflictClause{columns: columns}
+	return b
+}
+
+// OnConstraint adds an ON CONFLICT ON CONSTRAINT clause with the named
+// constraint as the conflict target.
+func (b *InsertBuilder) OnConstraint(name string) *InsertBuilder {
+	b.conflict = &conflictClause{constraint: name}
+	return b
+}
+
+// DoNothing skips rows which conflict.
+func (b *InsertBuilder) DoNothing() *InsertBuilder {
+	b.mustHaveConflict("DoNothing")
+	b.conflict.doNothing = true
+	return b
+}
+
+// DoUpdateSet appends a column/value pair to the SET clause used to update
+// conflicting rows. Use an Expression to reference the row proposed for
+// insertion, eg Expr("EXCLUDED.amount + 1").
+func (b *InsertBuilder) DoUpdateSet(column string, value interface{}) *InsertBuilder {
+	b.mustHaveConflict("DoUpdateSet")
+	b.conflict.setClauses = append(b.conflict.setClauses, &setClause{column: column, value: value})
+	return b
+}
+
+// DoUpdateSetExcluded sets columns of conflicting rows to the values proposed
+// for insertion, "col" = EXCLUDED."col".
+func (b *InsertBuilder) DoUpdateSetExcluded(columns ...string) *InsertBuilder {
+	b.mustHaveConflict("DoUpdateSetExcluded")
+	for _, col := range columns {
+		b.conflict.setClauses = append(b.conflict.setClauses, &setClause{column: col, value: excluded(col)})
+	}
+	return b
+}
+
+// DoUpdateWhere appends a WHERE clause which conflicting rows must match to
+// be updated.
+func (b *InsertBuilder) DoUpdateWhere(whereSQLOrMap interface{}, args ...interface{}) *InsertBuilder {
+	b.mustHaveConflict("DoUpdateWhere")
+	b.conflict.whereFragments = append(b.conflict.whereFragments, newWhereFragment(whereSQLOrMap, args))
+	return b
+}
+
+func (b *InsertBuilder) mustHaveConflict(method string) {
+	if b.conflict == nil {
+		panic(method + " requires OnConflict or OnConstraint")
+	}
+}
+
+// excluded references the column of the row proposed for insertion. The
+// identifier is quoted when the clause is written.
+type excluded string
+
 // Pair adds a key/value pair to the statement
 func (b *InsertBuilder) Pair(column string, value interface{}) *InsertBuilder {
 	b.cols = append(b.cols, column)
@@ -83,6 +207,7 @@ func (b *InsertBuilder) Pair(column string, value interface{}) *InsertBuilder {
 // ToSQL serialized the InsertBuilder to a SQL string
 // It returns the string with placeholders and a slice of query arguments
 func (b *InsertBuilder) ToSQL() (string, []interface{}) {
+	d := b.sqlDialect()
 	if len(b.table) == 0 {
 		panic("no table specified")
 	}
@@ -91,7 +216,11 @@ func (b *InsertBuilder) ToSQL() (string, []interface{}) {
 	if lenCols == 0 {
 		panic("no columns specified")
 	}
-	if len(b.vals) == 0 && lenRecords == 0 {
+	if b.fromSelect != nil {
+		if len(b.vals) > 0 || lenRecords > 0 {
+			panic("FromSelect cannot be used with Values or Record")
+		}
+	} else if len(b.vals) == 0 && lenRecords == 0 {
 		panic("no values or records specified")
 	}
 
@@ -103,18 +232,18 @@ func (b *InsertBuilder) ToSQL() (string, []interface{}) {
 		panic(`Blacklist can only be used in conjunction with Record`)
 	}
 
-	// reflect fields removing blacklisted columns
-	if lenRecords > 0 && b.isBlacklist {
-		b.cols = reflectExcludeColumns(b.records[0], b.cols)
-	}
-	// reflect all fields
-	if lenRecords > 0 && b.cols[0] == "*" {
-		b.cols = reflectColumns(b.records[0])
+	if lenRecords > 0 {
+		// resolve columns on a copy, ToSQL does not modify the builder
+		c := *b
+		c.cols = recordColumns(b.records[0], b.cols, b.isBlacklist)
+		b = &c
 	}
 
 	var sql bytes.Buffer
 	var args []interface{}
+	var pos int64 = 1
 
+	b.with.writeSQL(d, &sql, &args, &pos)
 	sql.WriteString("INSERT INTO ")
 	sql.WriteString(b.table)
 	sql.WriteString(" (")
@@ -123,17 +252,26 @@ func (b *InsertBuilder) ToSQL() (string, []interface{}) {
 		if i > 0 {
 			sql.WriteRune(',')
 		}
-		Dialect.WriteIdentifier(&sql, c)
+		d.WriteIdentifier(&sql, c)
+	}
+
+	start := int(pos)
+	if b.fromSelect != nil {
+		sql.WriteString(") ")
+		selectSQL, selectArgs := b.fromSelect.subquerySQL(d)
+		// map relative $1, $2 placeholders to absolute
+		remapPlaceholders(d, &sql, selectSQL, selectArgs, &args, &pos)
+		start = int(pos)
+	} else {
+		sql.WriteString(") VALUES ")
 	}
-	sql.WriteString(") VALUES ")
 
-	start := 1
 	// Go thru each value we want to insert. Write the placeholders, and collect args
 	for i, row := range b.vals {
 		if i > 0 {
 			sql.WriteRune(',')
 		}
-		buildPlaceholders(&sql, start, len(row))
+		buildPlaceholders(d, &sql, start, len(row))
 
 		for _, v := range row {
 			args = append(args, v)
@@ -153,22 +291,79 @@ func (b *InsertBuilder) ToSQL() (string, []interface{}) {
 		if err != nil {
 			panic(err.Error())
 		}
-		buildPlaceholders(&sql, start, len(vals))
+		buildPlaceholders(d, &sql, start, len(vals))
 		for _, v := range vals {
 			args = append(args, v)
 			start++
 		}
 	}
 
-	// Go thru the returning clauses
-	for i, c := range b.returnings {
-		if i == 0 {
-			sql.WriteString(" RETURNING ")
-		} else {
-			sql.WriteRune(',')
-		}
-		Dialect.WriteIdentifier(&sql, c)
+	if b.conflict != nil {
+		pos = int64(start)
+		b.conflict.writeSQL(d, &sql, &args, &pos)
 	}
 
+	writeReturning(d, &sql, b.returnings)
+
 	return sql.String(), args
 }
+
+// writeSQL writes the ON CONFLICT clause.
+func (c *conflictClause) writeSQL(d SQLDialect, buf common.BufferWriter, args *[]interface{}, pos *int64) {
+	if !d.SupportsOnConflict() {
+		panic(msgOnConflictUnsupported)
+	}
+	if c.doNothing && len(c.setClauses) > 0 {
+		panic("DoNothing and DoUpdateSet cannot be used together")
+	}
+	if !c.doNothing && len(c.setClauses) == 0 {
+		panic("OnConflict requires DoNothing or DoUpdateSet")
+	}
+	if !c.doNothing && len(c.columns) == 0 && c.constraint == "" {
+		panic("DoUpdateSet requires conflict columns or a constraint")
+	}
+	if len(c.whereFragments) > 0 && len(c.setClauses) == 0 {
+		panic("DoUpdateWhere can only be used in conjunction with DoUpdateSet")
+	}
+
+	buf.WriteString(" ON CONFLICT")
+	if c.constraint != "" {
+		buf.WriteString(" ON CONSTRAINT ")
+		writeIdentifier(d, buf, c.constraint)
+	} else if len(c.columns) > 0 {
+		buf.WriteString(" (")
+		writeIdentifiers(d, buf, c.columns, ",")
+		buf.WriteRune(')')
+	}
+
+	if c.doNothing {
+		buf.WriteString(" DO NOTHING")
+		return
+	}
+
+	buf.WriteString(" DO UPDATE SET ")
+	for i, sc := range c.setClauses {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		writeIdentifier(d, buf, sc.column)
+		switch v := sc.value.(type) {
+		case excluded:
+			buf.WriteString(" = EXCLUDED.")
+			writeIdentifier(d, buf, string(v))
+		case *Expression:
+			buf.WriteString(" = ")
+			// map relative $1, $2 placeholders to absolute
+			remapPlaceholders(d, buf, v.Sql, v.Args, args, pos)
+		default:
+			buf.WriteString(equalsPlaceholder(d, *pos))
+			*pos++
+			*args = append(*args, sc.value)
+		}
+	}
+
+	if len(c.whereFragments) > 0 {
+		buf.WriteString(" WHERE ")
+		writeAndFragmentsToSQL(d, buf, c.whereFragments, args, pos)
+	}
+}
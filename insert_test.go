@@ -4,6 +4,9 @@ import (
 	"strings"
 	"testing"
 
+	"gopkg.in/mgutz/dat.v1/mysql"
+	"gopkg.in/mgutz/dat.v1/postgres"
+	"gopkg.in/mgutz/dat.v1/sqlite"
 	"gopkg.in/stretchr/testify.v1/assert"
 )
 
@@ -115,3 +118,130 @@ func TestInsertDuplicateColumns(t *testing.T) {
 	assert.Equal(t, sql, `INSERT INTO a ("status") VALUES ($1)`)
 	assert.Equal(t, args, []interface{}{"open"})
 }
+
+func TestInsertReturningUnsupported(t *testing.T) {
+	Dialect = mysql.New()
+	defer func() {
+		Dialect = postgres.New()
+	}()
+
+	sql, args := InsertInto("a").Columns("b", "c").Values(1, true).ToSQL()
+	assert.Equal(t, "INSERT INTO a (`b`,`c`) VALUES (?,?)", sql)
+	assert.Equal(t, []interface{}{1, true}, args)
+
+	assert.Panics(t, func() {
+		InsertInto("a").Columns("b").Values(1).Returning("id").ToSQL()
+	})
+
+	// runners interpolate, which returns an error instead
+	_, _, err := InsertInto("a").Columns("b").Values(1).Returning("id").Interpolate()
+	assert.Equal(t, ErrInvalidOperation, err)
+	_, _, err = Update("a").Set("b", 1).Returning("id").Interpolate()
+	assert.Equal(t, ErrInvalidOperation, err)
+	_, _, err = DeleteFrom("a").Returning("id").Interpolate()
+	assert.Equal(t, ErrInvalidOperation, err)
+	_, _, err = InsertInto("a").Columns("b").Values(1).OnConflict("b").DoNothing().Interpolate()
+	assert.Equal(t, ErrInvalidOperation, err)
+}
+
+func TestInsertOnConflictDoNothing(t *testing.T) {
+	sql, args := InsertInto("a").Columns("b", "c").Values(1, 2).OnConflict().DoNothing().ToSQL()
+	assert.Equal(t, `INSERT INTO a ("b","c") VALUES ($1,$2) ON CONFLICT DO NOTHING`, sql)
+	assert.Equal(t, []interface{}{1, 2}, args)
+
+	sql, args = InsertInto("a").Columns("b", "c").Values(1, 2).OnConflict("b").DoNothing().ToSQL()
+	assert.Equal(t, `INSERT INTO a ("b","c") VALUES ($1,$2) ON CONFLICT ("b") DO NOTHING`, sql)
+	assert.Equal(t, []interface{}{1, 2}, args)
+}
+
+func TestInsertOnConflictDoUpdate(t *testing.T) {
+	objs := []someRecord{{1, 88, false}, {2, 99, true}}
+	sql, args := InsertInto("a").
+		Columns("something_id", "user_id", "other").
+		Record(objs[0]).
+		Record(objs[1]).
+		OnConflict("something_id").
+		DoUpdateSetExcluded("user_id").
+		DoUpdateSet("other", Expr("a.other OR EXCLUDED.other OR $1", true)).
+		DoUpdateSet("updated_at", NOW).
+		DoUpdateWhere("a.user_id <> $1", 0).
+		Returning("something_id", "user_id").
+		ToSQL()
+
+	assert.Equal(t, stripWS(`
+		INSERT INTO a ("something_id","user_id","other") VALUES ($1,$2,$3),($4,$5,$6)
+		ON CONFLICT ("something_id")
+		DO UPDATE SET "user_id" = EXCLUDED."user_id", "other" = a.other OR EXCLUDED.other OR $7, "updated_at" = $8
+		WHERE (a.user_id <> $9)
+		RETURNING "something_id","user_id"`), stripWS(sql))
+	checkSliceEqual(t, []interface{}{1, 88, false, 2, 99, true, true, NOW, 0}, args)
+}
+
+func TestInsertOnConstraint(t *testing.T) {
+	sql, args := InsertInto("a").Columns("b", "c").Values(1, 2).
+		OnConstraint("a_b_key").
+		DoUpdateSetExcluded("c").
+		ToSQL()
+	assert.Equal(t, `INSERT INTO a ("b","c") VALUES ($1,$2) ON CONFLICT ON CONSTRAINT "a_b_key" DO UPDATE SET "c" = EXCLUDED."c"`, sql)
+	assert.Equal(t, []interface{}{1, 2}, args)
+}
+
+func TestInsertOnConflictInvalid(t *testing.T) {
+	assert.Panics(t, func() {
+		InsertInto("a").Columns("b").Values(1).DoNothing()
+	})
+	assert.Panics(t, func() {
+		InsertInto("a").Columns("b").Values(1).OnConflict("b").ToSQL()
+	})
+	assert.Panics(t, func() {
+		InsertInto("a").Columns("b").Values(1).OnConflict("b").DoNothing().DoUpdateSet("b", 2).ToSQL()
+	})
+	assert.Panics(t, func() {
+		InsertInto("a").Columns("b").Values(1).OnConflict().DoUpdateSetExcluded("b").ToSQL()
+	})
+}
+
+func TestInsertOnConflictSQLite(t *testing.T) {
+	sql, args := InsertInto("a").Columns("b", "c").Values(1, 2).
+		OnConflict("b").
+		DoUpdateSet("c", Expr("c + $1", 1)).
+		DoUpdateWhere("c < $1", 10).
+		SetDialect(sqlite.New()).
+		ToSQL()
+	assert.Equal(t, `INSERT INTO a ("b","c") VALUES (?,?) ON CONFLICT ("b") DO UPDATE SET "c" = c + ? WHERE (c < ?)`, sql)
+	assert.Equal(t, []interface{}{1, 2, 1, 10}, args)
+}
+
+func TestInsertFromSelect(t *testing.T) {
+	sb := Select("id", "title").From("posts").Where("state = $1 AND deleted_at < $2", "deleted", NOW)
+	sql, args := InsertInto("archive").
+		Columns("post_id", "title").
+		FromSelect(sb).
+		OnConflict("post_id").
+		DoUpdateSet("archived_at", Expr("$1", NOW)).
+		Returning("id").
+		ToSQL()
+
+	assert.Equal(t, stripWS(`
+		INSERT INTO archive ("post_id","title")
+		SELECT id, title FROM posts WHERE (state = $1 AND deleted_at < $2)
+		ON CONFLICT ("post_id") DO UPDATE SET "archived_at" = $3
+		RETURNING "id"`), stripWS(sql))
+	assert.Equal(t, []interface{}{"deleted", NOW, NOW}, args)
+
+	assert.Panics(t, func() {
+		InsertInto("archive").Columns("post_id").Values(1).FromSelect(sb).ToSQL()
+	})
+}
+
+func TestInsertFromSelectDialect(t *testing.T) {
+	sb := Select("id").From("posts").Where("user_id = $1 OR author_id = $1", 1)
+	sql, args := InsertInto("archive").
+		Columns("post_id").
+		FromSelect(sb).
+		SetDialect(mysql.New()).
+		ToSQL()
+
+	assert.Equal(t, "INSERT INTO archive (`post_id`) SELECT id FROM posts WHERE (user_id = ? OR author_id = ?)", sql)
+	assert.Equal(t, []interface{}{1, 1}, args)
+}
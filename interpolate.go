@@ -1,11 +1,9 @@
 package dat
 
 import (
-	"bytes"
 	"database/sql/driver"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 	"unicode/utf8"
 )
@@ -31,7 +29,7 @@ func isFloat(k reflect.Kind) bool {
 		k == reflect.Float64
 }
 
-// sql is like "id = $1 OR username = $2"
+// sql is like "id = $1 OR username = $2", or in the dialect's placeholder style
 // vals is like []interface{}{4, "bob"}
 // NOTE that vals can only have values of certain types:
 //   - Integers (signed and unsigned)
@@ -45,6 +43,11 @@ var typeOfTime = reflect.TypeOf(time.Time{})
 // replace them with. Returns a blank string and error if the number of placeholders
 // does not match the number of arguments.
 func Interpolate(sql string, vals []interface{}) (string, []interface{}, error) {
+	return interpolateSQL(Dialect, sql, vals)
+}
+
+// interpolateSQL interpolates sql with the literals and placeholders of d.
+func interpolateSQL(d SQLDialect, sql string, vals []interface{}) (string, []interface{}, error) {
 	// Get the number of arguments to add to this query
 	lenVals := len(vals)
 
@@ -66,8 +69,9 @@ func Interpolate(sql string, vals []interface{}) (string, []interface{}, error)
 		return "", nil, nil
 	}
 
+	style := d.PlaceholderStyle()
 	if Strict {
-		hasPlaceholders := strings.Contains(sql, "$")
+		hasPlaceholders := hasPlaceholders(sql, style)
 
 		// If we have no args and the query has no place holders return early
 		// No args for a query with place holders is an error
@@ -89,8 +93,6 @@ func Interpolate(sql string, vals []interface{}) (string, []interface{}, error)
 
 	buf := bufPool.Get()
 	defer bufPool.Put(buf)
-	var accumulateDigits bool
-	var digits bytes.Buffer
 
 	newPlaceholderIndex := 0
 	var newArgs []interface{}
@@ -107,7 +109,7 @@ func Interpolate(sql string, vals []interface{}) (string, []interface{}, error)
 		var passthroughArg = func(values ...interface{}) {
 			newPlaceholderIndex++
 			newArgs = append(newArgs, values...)
-			writePlaceholder(buf, newPlaceholderIndex)
+			writePlaceholder(d, buf, newPlaceholderIndex)
 		}
 
 		if val, ok := v.(UnsafeString); ok {
@@ -129,7 +131,14 @@ func Interpolate(sql string, vals []interface{}) (string, []interface{}, error)
 				return nil
 			}
 
-			s, args, err := valuer.Expression()
+			var s string
+			var args []interface{}
+			var err error
+			if exp, ok := v.(*Expression); ok {
+				s, args, err = interpolateSQL(d, exp.Sql, exp.Args)
+			} else {
+				s, args, err = valuer.Expression()
+			}
 			if err != nil {
 				return err
 			}
@@ -150,7 +159,7 @@ func Interpolate(sql string, vals []interface{}) (string, []interface{}, error)
 			if err != nil {
 				return err
 			}
-			Dialect.WriteStringLiteral(buf, s)
+			d.WriteStringLiteral(buf, s)
 			return nil
 		} else if valuer, ok := v.(driver.Valuer); ok {
 			val, err := valuer.Value()
@@ -183,7 +192,7 @@ func Interpolate(sql string, vals []interface{}) (string, []interface{}, error)
 			if !utf8.ValidString(str) {
 				return ErrNotUTF8
 			}
-			Dialect.WriteStringLiteral(buf, str)
+			d.WriteStringLiteral(buf, str)
 		} else if isInt(kindOfV) {
 			var ival = valueOfV.Int()
 			writeInt64(buf, ival)
@@ -194,16 +203,11 @@ func Interpolate(sql string, vals []interface{}) (string, []interface{}, error)
 			var fval = valueOfV.Float()
 			buf.WriteString(strconv.FormatFloat(fval, 'f', -1, 64))
 		} else if kindOfV == reflect.Bool {
-			var bval = valueOfV.Bool()
-			if bval {
-				buf.WriteString(`'t'`)
-			} else {
-				buf.WriteString(`'f'`)
-			}
+			d.WriteBoolLiteral(buf, valueOfV.Bool())
 		} else if kindOfV == reflect.Struct {
 			if typeOfV := valueOfV.Type(); typeOfV == typeOfTime {
 				t := valueOfV.Interface().(time.Time)
-				Dialect.WriteFormattedTime(buf, t)
+				d.WriteFormattedTime(buf, t)
 			} else {
 				return ErrInvalidValue
 			}
@@ -243,7 +247,7 @@ func Interpolate(sql string, vals []interface{}) (string, []interface{}, error)
 					if !utf8.ValidString(str) {
 						return ErrNotUTF8
 					}
-					Dialect.WriteStringLiteral(buf, str)
+					d.WriteStringLiteral(buf, str)
 				}
 			} else {
 				return ErrInvalidSliceValue
@@ -256,61 +260,27 @@ func Interpolate(sql string, vals []interface{}) (string, []interface{}, error)
 		return nil
 	}
 
-	lenSQL := len(sql)
-	done := false
-	for i, r := range sql {
-		if accumulateDigits {
-			if '0' <= r && r <= '9' {
-				digits.WriteRune(r)
-				if i < lenSQL-1 {
-					continue
-				}
-				// last rune is part of a placeholder, fallthrough
-				done = true
-			}
-
-			digitsStr := digits.String()
-			// can be empty $ is followed by a non-digit
-			if digitsStr == "" {
-				buf.WriteRune('$')
-				buf.WriteRune(r)
-				accumulateDigits = false
-				continue
-			}
-
-			pos := 0
-			if len(digitsStr) > 2 {
-				pos, _ = strconv.Atoi(digitsStr)
-			} else {
-				pos, _ = atoiTab[digitsStr]
-			}
-			err := writeValue(pos - 1)
-			if err != nil {
-				return "", nil, err
-			}
-
-			if done {
-				break
-			}
-			accumulateDigits = false
-		}
-
-		if r == '$' && i < lenSQL-1 {
-			digits.Reset()
-			accumulateDigits = true
-			continue
+	err := splitPlaceholders(sql, style, func(text string, n int) error {
+		buf.WriteString(text)
+		if n == 0 {
+			return nil
 		}
-
-		buf.WriteRune(r)
+		return writeValue(n - 1)
+	})
+	if err != nil {
+		return "", nil, err
 	}
 
 	return buf.String(), newArgs, nil
 }
 
-func interpolate(builder Builder) (string, []interface{}, error) {
+func interpolate(d SQLDialect, builder Builder) (string, []interface{}, error) {
+	if err := checkDialect(d, builder); err != nil {
+		return "", nil, err
+	}
 	sql, args := builder.ToSQL()
 	if builder.IsInterpolated() {
-		return Interpolate(sql, args)
+		return interpolateSQL(d, sql, args)
 	}
 	return sql, args, nil
 }
@@ -0,0 +1,77 @@
+package dat
+
+import "gopkg.in/mgutz/dat.v1/common"
+
+// joinClause is a JOIN of a table or sub query in a FROM clause.
+type joinClause struct {
+	kind      string
+	table     string
+	sub       Builder
+	alias     string
+	isLateral bool
+	on        *whereFragment
+}
+
+// newJoinClause creates a JOIN of table, a table name or Builder. on is
+// required except for CROSS JOIN, and LATERAL which joins ON TRUE.
+func newJoinClause(kind string, isLateral bool, table interface{}, alias string, on string, args []interface{}) *joinClause {
+	j := &joinClause{kind: kind, alias: alias, isLateral: isLateral}
+	switch t := table.(type) {
+	case string:
+		if t == "" {
+			panic(kind + " requires a table")
+		}
+		j.table = t
+	case Builder:
+		if alias == "" {
+			panic(kind + " of a sub query requires an alias")
+		}
+		j.sub = t
+	default:
+		panic(kind + " accepts only {string, Builder} table")
+	}
+	if on != "" {
+		j.on = &whereFragment{Condition: on, Values: args}
+	} else if kind != "CROSS JOIN" && !isLateral {
+		panic(kind + " requires an ON condition")
+	}
+	return j
+}
+
+func (j *joinClause) clone() *joinClause {
+	clone := *j
+	if j.sub != nil {
+		clone.sub = cloneBuilder(j.sub)
+	}
+	return &clone
+}
+
+// writeSQL writes " KIND [LATERAL] table [alias] [ON condition]".
+func (j *joinClause) writeSQL(d SQLDialect, buf common.BufferWriter, args *[]interface{}, pos *int64) {
+	buf.WriteRune(' ')
+	buf.WriteString(j.kind)
+	buf.WriteRune(' ')
+	if j.isLateral {
+		buf.WriteString("LATERAL ")
+	}
+	if j.sub != nil {
+		sql, subArgs := builderSQL(d, j.sub)
+		buf.WriteRune('(')
+		remapPlaceholders(d, buf, sql, subArgs, args, pos)
+		buf.WriteString(") AS ")
+		buf.WriteString(j.alias)
+	} else {
+		buf.WriteString(j.table)
+		if j.alias != "" {
+			buf.WriteRune(' ')
+			buf.WriteString(j.alias)
+		}
+	}
+
+	if j.on != nil {
+		buf.WriteString(" ON ")
+		remapPlaceholders(d, buf, j.on.Condition, j.on.Values, args, pos)
+	} else if j.isLateral {
+		buf.WriteString(" ON TRUE")
+	}
+}
@@ -0,0 +1,124 @@
+package dat
+
+import (
+	"testing"
+
+	"gopkg.in/mgutz/dat.v1/mysql"
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+func TestSelectJoins(t *testing.T) {
+	sql, args := Select("u.name", "p.title", "c.comment").
+		From("users u").
+		Join("posts", "p", "p.user_id = u.id AND p.state = $1", "published").
+		LeftJoin("comments", "c", "c.post_id = p.id AND c.created_at > $1", 100).
+		RightJoin("teams t", "", "t.id = u.team_id").
+		FullJoin("audits", "", "audits.user_id = u.id").
+		CrossJoin("settings", "s").
+		Where("u.id = $1", 1).
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT u.name, p.title, c.comment
+		FROM users u
+			INNER JOIN posts p ON p.user_id = u.id AND p.state = $1
+			LEFT JOIN comments c ON c.post_id = p.id AND c.created_at > $2
+			RIGHT JOIN teams t ON t.id = u.team_id
+			FULL JOIN audits ON audits.user_id = u.id
+			CROSS JOIN settings s
+		WHERE (u.id = $3)`), stripWS(sql))
+	assert.Exactly(t, []interface{}{"published", 100, 1}, args)
+}
+
+func TestSelectJoinSub(t *testing.T) {
+	counts := Select("user_id", "count(*) AS n").From("posts").Where("state = $1", "published").GroupBy("user_id")
+	sql, args := Select("u.name", "pc.n").
+		With("active", "SELECT id FROM users WHERE active = $1", true).
+		From("users u").
+		LeftJoin(counts, "pc", "pc.user_id = u.id AND pc.n > $1", 2).
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		WITH active AS (SELECT id FROM users WHERE active = $1)
+		SELECT u.name, pc.n
+		FROM users u
+			LEFT JOIN (SELECT user_id, count(*) AS n FROM posts WHERE (state = $2) GROUP BY user_id) AS pc
+				ON pc.user_id = u.id AND pc.n > $3`), stripWS(sql))
+	assert.Exactly(t, []interface{}{true, "published", 2}, args)
+}
+
+func TestSelectJoinLateral(t *testing.T) {
+	latest := Select("title").From("posts").Where("user_id = u.id AND state = $1", "published").OrderBy("id DESC").Limit(1)
+	sql, args := Select("u.name", "p.title").
+		From("users u").
+		JoinLateral(latest, "p", "").
+		Where("u.id = $1", 1).
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT u.name, p.title
+		FROM users u
+			INNER JOIN LATERAL (SELECT title FROM posts WHERE (user_id = u.id AND state = $1) ORDER BY id DESC LIMIT 1) AS p ON TRUE
+		WHERE (u.id = $2)`), stripWS(sql))
+	assert.Exactly(t, []interface{}{"published", 1}, args)
+}
+
+func TestSelectJoinScope(t *testing.T) {
+	sql, args := Select("u.*").
+		From("users u").
+		Join("posts", "p", "p.user_id = u.id").
+		Scope("INNER JOIN comments c ON c.post_id = p.id WHERE c.state = $1", "spam").
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT u.*
+		FROM users u
+			INNER JOIN posts p ON p.user_id = u.id
+			INNER JOIN comments c ON c.post_id = p.id
+		WHERE (c.state = $1)`), stripWS(sql))
+	assert.Exactly(t, []interface{}{"spam"}, args)
+}
+
+func TestSelectJoinQuestion(t *testing.T) {
+	sql, args := Select("u.name").
+		From("users u").
+		Join("posts", "p", "p.user_id = u.id AND p.state = $1", "published").
+		Where("u.id = $1", 1).
+		SetDialect(mysql.New()).
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT u.name
+		FROM users u INNER JOIN posts p ON p.user_id = u.id AND p.state = ?
+		WHERE (u.id = ?)`), stripWS(sql))
+	assert.Exactly(t, []interface{}{"published", 1}, args)
+}
+
+func TestSelectDocJoin(t *testing.T) {
+	sql, args := SelectDoc("u.id", "p.title").
+		From("users u").
+		LeftJoin("posts", "p", "p.user_id = u.id AND p.state = $1", "published").
+		Where("u.id = $1", 1).
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT row_to_json(dat__item.*)
+		FROM (
+			SELECT u.id, p.title
+			FROM users u LEFT JOIN posts p ON p.user_id = u.id AND p.state = $1
+			WHERE (u.id = $2)
+		) as dat__item`), stripWS(sql))
+	assert.Exactly(t, []interface{}{"published", 1}, args)
+}
+
+func TestSelectJoinInvalid(t *testing.T) {
+	assert.Panics(t, func() {
+		Select("*").From("users").Join(1, "", "true")
+	})
+	assert.Panics(t, func() {
+		Select("*").From("users").Join(Select("id").From("posts"), "", "true")
+	})
+	assert.Panics(t, func() {
+		Select("*").From("users").LeftJoin("", "p", "true")
+	})
+	assert.Panics(t, func() {
+		Select("*").From("users").Join("posts", "p", "")
+	})
+	assert.Panics(t, func() {
+		SelectDoc("*").From("users").FullJoin("posts", "p", "")
+	})
+}
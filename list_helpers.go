@@ -6,37 +6,153 @@ import (
 
 var bufPool = common.NewBufferPool()
 
-func writeIdentifiers(buf common.BufferWriter, columns []string, join string) {
+func writeIdentifiers(d SQLDialect, buf common.BufferWriter, columns []string, join string) {
 	for i, column := range columns {
 		if i > 0 {
 			buf.WriteString(join)
 		}
-		Dialect.WriteIdentifier(buf, column)
+		d.WriteIdentifier(buf, column)
 	}
 }
 
-func writeIdentifier(buf common.BufferWriter, name string) {
-	Dialect.WriteIdentifier(buf, name)
+func writeIdentifier(d SQLDialect, buf common.BufferWriter, name string) {
+	d.WriteIdentifier(buf, name)
 }
 
-func buildPlaceholders(buf common.BufferWriter, start, length int) {
+const (
+	msgReturningUnsupported   = "RETURNING is not supported by the dialect, use Result.LastInsertID"
+	msgOnConflictUnsupported  = "ON CONFLICT is not supported by the dialect"
+	msgUpsertWhereUnsupported = "Upsert with Where is not supported by the dialect, use Key"
+	msgInsectUnsupported      = "Insect is not supported by the dialect, it requires RETURNING"
+	msgUpdateFromUnsupported  = "UPDATE ... FROM is not supported by the dialect"
+	msgDeleteUsingUnsupported = "DELETE ... USING is not supported by the dialect"
+)
+
+// dialectChecker is implemented by builders with clauses which some dialects
+// do not support.
+type dialectChecker interface {
+	checkDialect(d SQLDialect) error
+}
+
+// checkDialect returns ErrInvalidOperation if b uses a clause d does not
+// support. ToSQL panics on such a builder, Interpolate returns the error so
+// runners fail the query instead.
+func checkDialect(d SQLDialect, b Builder) error {
+	if c, ok := b.(dialectChecker); ok {
+		return c.checkDialect(d)
+	}
+	return nil
+}
+
+// unsupported logs msg and returns ErrInvalidOperation.
+func unsupported(msg string) error {
+	return logger.Error(msg, "err", ErrInvalidOperation)
+}
+
+// checkReturning returns ErrInvalidOperation if there are RETURNING columns
+// and d does not support RETURNING.
+func checkReturning(d SQLDialect, columns []string) error {
+	if len(columns) > 0 && !d.SupportsReturning() {
+		return unsupported(msgReturningUnsupported)
+	}
+	return nil
+}
+
+// writeReturning writes the RETURNING clause for columns, if any. It panics
+// if the dialect does not support RETURNING.
+func writeReturning(d SQLDialect, buf common.BufferWriter, columns []string) {
+	if len(columns) == 0 {
+		return
+	}
+	if !d.SupportsReturning() {
+		panic(msgReturningUnsupported)
+	}
+	buf.WriteString(" RETURNING ")
+	writeIdentifiers(d, buf, columns, ",")
+}
+
+func buildPlaceholders(d SQLDialect, buf common.BufferWriter, start, length int) {
 	// Build the placeholder like "($1,$2,$3)"
 	buf.WriteRune('(')
 	for i := start; i < start+length; i++ {
 		if i > start {
 			buf.WriteRune(',')
 		}
-		writePlaceholder(buf, i)
+		writePlaceholder(d, buf, i)
 	}
 	buf.WriteRune(')')
 }
 
 // joinPlaceholders returns $1, $2 ... , $n
-func writePlaceholders(buf common.BufferWriter, length int, join string, offset int) {
+func writePlaceholders(d SQLDialect, buf common.BufferWriter, length int, join string, offset int) {
+	for i := 0; i < length; i++ {
+		if i > 0 {
+			buf.WriteString(join)
+		}
+		writePlaceholder(d, buf, i+offset)
+	}
+}
+
+// writeReusedPlaceholders writes placeholders referencing args already bound
+// at offset, offset+1 ... Positional ? placeholders cannot reference an
+// earlier arg, so those args are bound again.
+func writeReusedPlaceholders(d SQLDialect, buf common.BufferWriter, args *[]interface{}, length int, join string, offset int) {
+	if d.PlaceholderStyle() != common.QuestionPlaceholder {
+		writePlaceholders(d, buf, length, join, offset)
+		return
+	}
+
 	for i := 0; i < length; i++ {
 		if i > 0 {
 			buf.WriteString(join)
 		}
-		writePlaceholder(buf, i+offset)
+		buf.WriteRune('?')
+		*args = append(*args, (*args)[offset-1+i])
+	}
+}
+
+// writeNewValues writes a SELECT of rows for a new_values CTE. Parameters in
+// a VALUES list are resolved as text, so rows are a UNION ALL following an
+// empty SELECT from table, which gives each parameter the type of its column.
+// If ordinal is set, the _ord column numbers the rows from 1.
+func writeNewValues(d SQLDialect, buf common.BufferWriter, table string, columns []string, rows [][]interface{}, ordinal bool, args *[]interface{}) {
+	buf.WriteString("SELECT ")
+	writeIdentifiers(d, buf, columns, ",")
+	if ordinal {
+		buf.WriteString(",0 AS _ord")
+	}
+	buf.WriteString(" FROM ")
+	writeIdentifier(d, buf, table)
+	buf.WriteString(" WHERE false")
+
+	start := len(*args) + 1
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			panic("number of values does not match number of columns")
+		}
+		buf.WriteString(" UNION ALL SELECT ")
+		writePlaceholders(d, buf, len(row), ",", start)
+		if ordinal {
+			buf.WriteRune(',')
+			writeInt64(buf, int64(i+1))
+		}
+		*args = append(*args, row...)
+		start += len(row)
+	}
+}
+
+// writeKeysMatch writes "table"."key" = alias."key" for each key.
+func writeKeysMatch(d SQLDialect, buf common.BufferWriter, table string, alias string, keys []string) {
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteString(" AND ")
+		}
+		writeIdentifier(d, buf, table)
+		buf.WriteRune('.')
+		writeIdentifier(d, buf, key)
+		buf.WriteString(" = ")
+		buf.WriteString(alias)
+		buf.WriteRune('.')
+		writeIdentifier(d, buf, key)
 	}
 }
@@ -0,0 +1,121 @@
+package mysql
+
+import (
+	"strings"
+	"time"
+
+	"gopkg.in/mgutz/dat.v1/common"
+)
+
+// MySQL is the MySQL dialect.
+type MySQL struct{}
+
+// New returns a new MySQL dialect.
+func New() *MySQL {
+	return &MySQL{}
+}
+
+// WriteStringLiteral writes an escaped string.
+//
+// Escaping follows mysql_real_escape_string and relies on backslash escape
+// sequences, which are disabled by the NO_BACKSLASH_ESCAPES SQL mode.
+func (md *MySQL) WriteStringLiteral(buf common.BufferWriter, val string) {
+	buf.WriteRune('\'')
+	if strings.ContainsAny(val, "\x00\n\r\\'\"\x1a") {
+		for _, char := range val {
+			switch char {
+			case 0:
+				buf.WriteString(`\0`)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\\':
+				buf.WriteString(`\\`)
+			case '\'':
+				buf.WriteString(`\'`)
+			case '"':
+				buf.WriteString(`\"`)
+			case '\x1a':
+				buf.WriteString(`\Z`)
+			default:
+				buf.WriteRune(char)
+			}
+		}
+	} else {
+		buf.WriteString(val)
+	}
+	buf.WriteRune('\'')
+}
+
+// WriteIdentifier writes escaped identifier.
+func (md *MySQL) WriteIdentifier(buf common.BufferWriter, ident string) {
+	if ident == "" {
+		panic("Identifier is empty string")
+	}
+	if ident == "*" {
+		buf.WriteString(ident)
+		return
+	}
+
+	buf.WriteRune('`')
+	buf.WriteString(strings.Replace(ident, "`", "``", -1))
+	buf.WriteRune('`')
+}
+
+// WriteFormattedTime formats t into a format MySQL understands. DATETIME
+// does not store a time zone, so t is written in UTC.
+func (md *MySQL) WriteFormattedTime(buf common.BufferWriter, t time.Time) {
+	buf.WriteRune('\'')
+	buf.WriteString(t.UTC().Format("2006-01-02 15:04:05.999999"))
+	buf.WriteRune('\'')
+}
+
+// PlaceholderStyle returns ? placeholders.
+func (md *MySQL) PlaceholderStyle() common.PlaceholderStyle {
+	return common.QuestionPlaceholder
+}
+
+// WriteBoolLiteral writes a boolean literal.
+func (md *MySQL) WriteBoolLiteral(buf common.BufferWriter, b bool) {
+	if b {
+		buf.WriteString("TRUE")
+	} else {
+		buf.WriteString("FALSE")
+	}
+}
+
+// SupportsReturning returns false, MySQL does not support RETURNING. Use
+// LAST_INSERT_ID through Result.LastInsertID instead.
+func (md *MySQL) SupportsReturning() bool {
+	return false
+}
+
+// SupportsWritableCTE returns false, MySQL only allows SELECT in WITH.
+func (md *MySQL) SupportsWritableCTE() bool {
+	return false
+}
+
+// SupportsOnConflict returns false, MySQL has ON DUPLICATE KEY UPDATE
+// instead, which Upsert uses.
+func (md *MySQL) SupportsOnConflict() bool {
+	return false
+}
+
+// SupportsUpdateFrom returns false, MySQL joins tables in UPDATE with a
+// different syntax.
+func (md *MySQL) SupportsUpdateFrom() bool {
+	return false
+}
+
+// SupportsDeleteUsing returns false, MySQL joins tables in DELETE with a
+// different syntax.
+func (md *MySQL) SupportsDeleteUsing() bool {
+	return false
+}
+
+// RowIDColumn returns an empty string, MySQL accepts ORDER BY and LIMIT in
+// DELETE.
+func (md *MySQL) RowIDColumn() string {
+	return ""
+}
@@ -0,0 +1,234 @@
+package dat
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/mgutz/dat.v1/common"
+)
+
+// formatPlaceholder formats the placeholder at 1-based pos in style.
+func formatPlaceholder(style common.PlaceholderStyle, pos int) string {
+	switch style {
+	case common.QuestionPlaceholder:
+		return "?"
+	case common.ColonPlaceholder:
+		return ":p" + strconv.Itoa(pos)
+	case common.AtPlaceholder:
+		return "@p" + strconv.Itoa(pos)
+	default:
+		return "$" + strconv.Itoa(pos)
+	}
+}
+
+func writePlaceholder(d SQLDialect, buf common.BufferWriter, pos int) {
+	style := d.PlaceholderStyle()
+	if pos < maxLookup {
+		buf.WriteString(placeholderTab[style][pos])
+	} else {
+		buf.WriteString(formatPlaceholder(style, pos))
+	}
+}
+
+// equalsPlaceholder returns " = $1"
+func equalsPlaceholder(d SQLDialect, pos int64) string {
+	style := d.PlaceholderStyle()
+	if pos < maxLookup {
+		return equalsPlaceholderTab[style][pos]
+	}
+	return " = " + formatPlaceholder(style, int(pos))
+}
+
+// inPlaceholder returns " IN $1"
+func inPlaceholder(d SQLDialect, pos int64) string {
+	style := d.PlaceholderStyle()
+	if pos < maxLookup {
+		return inPlaceholderTab[style][pos]
+	}
+	return " IN " + formatPlaceholder(style, int(pos))
+}
+
+// hasPlaceholders reports whether statement may contain placeholders.
+func hasPlaceholders(statement string, style common.PlaceholderStyle) bool {
+	switch style {
+	case common.QuestionPlaceholder:
+		return strings.ContainsAny(statement, "$?")
+	case common.ColonPlaceholder:
+		return strings.ContainsAny(statement, "$:")
+	case common.AtPlaceholder:
+		return strings.ContainsAny(statement, "$@")
+	default:
+		return strings.Contains(statement, "$")
+	}
+}
+
+// scanDigits returns the number starting at s[i] and the index after it,
+// or 0 if there are no digits.
+func scanDigits(s string, i int) (int, int) {
+	n := 0
+	j := i
+	for ; j < len(s) && '0' <= s[j] && s[j] <= '9'; j++ {
+		n = n*10 + int(s[j]-'0')
+	}
+	if j == i {
+		return 0, i
+	}
+	return n, j
+}
+
+// splitPlaceholders calls fn with the text preceding each placeholder in
+// statement and the placeholder's 1-based position, then with the remaining
+// text and 0. Both $n and the style's own form are recognized, ?
+// placeholders are numbered in order of appearance. Quoted literals and
+// identifiers are skipped.
+func splitPlaceholders(statement string, style common.PlaceholderStyle, fn func(text string, n int) error) error {
+	start := 0
+	ordinal := 0
+	var quote byte
+	for i := 0; i < len(statement); i++ {
+		c := statement[i]
+		if quote != 0 {
+			// MySQL is the only dialect with backslash escapes
+			if c == '\\' && style == common.QuestionPlaceholder {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		n, end := 0, 0
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '$':
+			n, end = scanDigits(statement, i+1)
+		case '?':
+			if style == common.QuestionPlaceholder {
+				ordinal++
+				n, end = ordinal, i+1
+			}
+		case ':':
+			// not a ::cast
+			if style == common.ColonPlaceholder && (i == 0 || statement[i-1] != ':') &&
+				i+1 < len(statement) && statement[i+1] == 'p' {
+				n, end = scanDigits(statement, i+2)
+			}
+		case '@':
+			if style == common.AtPlaceholder && i+1 < len(statement) && statement[i+1] == 'p' {
+				n, end = scanDigits(statement, i+2)
+			}
+		}
+		if n == 0 {
+			continue
+		}
+
+		if err := fn(statement[start:i], n); err != nil {
+			return err
+		}
+		start = end
+		i = end - 1
+	}
+	return fn(statement[start:], 0)
+}
+
+// remapPlaceholders writes statement, whose placeholders are relative to
+// values, to buf with placeholders in the dialect's style starting at pos.
+// The referenced values are appended to args and pos is advanced.
+//
+// A value which is a Builder is inlined in parentheses in place of its
+// placeholder, with its args remapped the same way.
+func remapPlaceholders(d SQLDialect, buf common.BufferWriter, statement string, values []interface{}, args *[]interface{}, pos *int64) {
+	style := d.PlaceholderStyle()
+	if hasBuilderValues(values) {
+		remapBuilderPlaceholders(d, buf, statement, values, args, pos)
+		return
+	}
+	if !hasPlaceholders(statement, style) {
+		buf.WriteString(statement)
+		*args = append(*args, values...)
+		*pos += int64(len(values))
+		return
+	}
+
+	// Positional placeholders cannot be reused or reordered, each one binds
+	// its value again.
+	if style == common.QuestionPlaceholder {
+		splitPlaceholders(statement, style, func(text string, n int) error {
+			buf.WriteString(text)
+			if n == 0 {
+				return nil
+			}
+			if n > len(values) {
+				panic("placeholder $" + strconv.Itoa(n) + " has no argument: " + statement)
+			}
+			buf.WriteRune('?')
+			*args = append(*args, values[n-1])
+			*pos++
+			return nil
+		})
+		return
+	}
+
+	offset := int(*pos) - 1 // 0-based
+	splitPlaceholders(statement, style, func(text string, n int) error {
+		buf.WriteString(text)
+		if n > 0 {
+			writePlaceholder(d, buf, offset+n)
+		}
+		return nil
+	})
+	*args = append(*args, values...)
+	*pos += int64(len(values))
+}
+
+// hasBuilderValues reports whether any of values is a Builder.
+func hasBuilderValues(values []interface{}) bool {
+	for _, v := range values {
+		if _, ok := v.(Builder); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// remapBuilderPlaceholders is remapPlaceholders for values which include
+// builders. Other values are numbered first, then the args of each builder
+// in order of appearance.
+func remapBuilderPlaceholders(d SQLDialect, buf common.BufferWriter, statement string, values []interface{}, args *[]interface{}, pos *int64) {
+	style := d.PlaceholderStyle()
+	positions := make([]int64, len(values))
+	if style != common.QuestionPlaceholder {
+		for i, v := range values {
+			if _, ok := v.(Builder); !ok {
+				positions[i] = *pos
+				*args = append(*args, v)
+				*pos++
+			}
+		}
+	}
+
+	splitPlaceholders(statement, style, func(text string, n int) error {
+		buf.WriteString(text)
+		if n == 0 {
+			return nil
+		}
+		if n > len(values) {
+			panic("placeholder $" + strconv.Itoa(n) + " has no argument: " + statement)
+		}
+
+		if sub, ok := values[n-1].(Builder); ok {
+			sql, subArgs := builderSQL(d, sub)
+			buf.WriteRune('(')
+			remapPlaceholders(d, buf, sql, subArgs, args, pos)
+			buf.WriteRune(')')
+		} else if style == common.QuestionPlaceholder {
+			buf.WriteRune('?')
+			*args = append(*args, values[n-1])
+			*pos++
+		} else {
+			writePlaceholder(d, buf, int(positions[n-1]))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,108 @@
+package dat
+
+import (
+	"testing"
+
+	"gopkg.in/mgutz/dat.v1/common"
+	"gopkg.in/mgutz/dat.v1/postgres"
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+// styleDialect is Postgres with another placeholder style.
+type styleDialect struct {
+	*postgres.Postgres
+	style common.PlaceholderStyle
+}
+
+func (d *styleDialect) PlaceholderStyle() common.PlaceholderStyle {
+	return d.style
+}
+
+func withPlaceholderStyle(style common.PlaceholderStyle, fn func()) {
+	Dialect = &styleDialect{postgres.New(), style}
+	defer func() {
+		Dialect = postgres.New()
+	}()
+	fn()
+}
+
+func TestPlaceholderStyles(t *testing.T) {
+	cases := map[common.PlaceholderStyle]string{
+		common.DollarPlaceholder:   `SELECT a FROM b WHERE (c = $1 OR d = $2) AND (e = $3)`,
+		common.ColonPlaceholder:    `SELECT a FROM b WHERE (c = :p1 OR d = :p2) AND (e = :p3)`,
+		common.AtPlaceholder:       `SELECT a FROM b WHERE (c = @p1 OR d = @p2) AND (e = @p3)`,
+		common.QuestionPlaceholder: `SELECT a FROM b WHERE (c = ? OR d = ?) AND (e = ?)`,
+	}
+	for style, expected := range cases {
+		withPlaceholderStyle(style, func() {
+			sql, args := Select("a").From("b").Where("c = $1 OR d = $2", 1, 2).Where("e = $1", 3).ToSQL()
+			assert.Equal(t, expected, sql)
+			assert.Equal(t, []interface{}{1, 2, 3}, args)
+		})
+	}
+}
+
+func TestPlaceholderQuestionReorder(t *testing.T) {
+	withPlaceholderStyle(common.QuestionPlaceholder, func() {
+		sql, args := Select("a").From("b").
+			Where("c = $2 OR d = $1 OR e = $2", 1, 2).
+			Where("f = ? AND g = '?'", 3).
+			ToSQL()
+		assert.Equal(t, `SELECT a FROM b WHERE (c = ? OR d = ? OR e = ?) AND (f = ? AND g = '?')`, sql)
+		assert.Equal(t, []interface{}{2, 1, 2, 3}, args)
+	})
+}
+
+func TestPlaceholderQuestionEq(t *testing.T) {
+	withPlaceholderStyle(common.QuestionPlaceholder, func() {
+		sql, args := Update("a").Set("b", 1).Where(Eq{"c": []int{2, 3}}).ToSQL()
+		assert.Equal(t, `UPDATE "a" SET "b" = ? WHERE ("c" IN ?)`, sql)
+		assert.Equal(t, []interface{}{1, []int{2, 3}}, args)
+	})
+}
+
+func TestPlaceholderQuestionUpsert(t *testing.T) {
+	withPlaceholderStyle(common.QuestionPlaceholder, func() {
+		_, args := Upsert("tab").Columns("b", "c").Values(1, 2).Where("d=$1", 4).ToSQL()
+		// SELECT ?,? binds the values again
+		assert.Equal(t, []interface{}{1, 2, 4, 1, 2}, args)
+	})
+}
+
+func TestPlaceholderColonCast(t *testing.T) {
+	withPlaceholderStyle(common.ColonPlaceholder, func() {
+		sql, args := SQL("SELECT $1::int, :p2::text", 1, "a").ToSQL()
+		assert.Equal(t, "SELECT :p1::int, :p2::text", sql)
+		assert.Equal(t, []interface{}{1, "a"}, args)
+	})
+}
+
+func TestPlaceholderRaw(t *testing.T) {
+	withPlaceholderStyle(common.QuestionPlaceholder, func() {
+		sql, args := SQL("SELECT $2, $1, 'it''s $1'", 1, 2).ToSQL()
+		assert.Equal(t, "SELECT ?, ?, 'it''s $1'", sql)
+		assert.Equal(t, []interface{}{2, 1}, args)
+	})
+}
+
+func TestInterpolateQuestion(t *testing.T) {
+	withPlaceholderStyle(common.QuestionPlaceholder, func() {
+		str, args, err := Interpolate("SELECT * FROM x WHERE a = ? AND b = 'why?' AND c = ?", []interface{}{1, "two"})
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM x WHERE a = 1 AND b = 'why?' AND c = 'two'", str)
+		assert.Nil(t, args)
+
+		str, args, err = Interpolate("SELECT * FROM x WHERE a = ? AND b = ?", []interface{}{1, JSON([]byte("{}"))})
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM x WHERE a = 1 AND b = ?", str)
+		assert.Equal(t, 1, len(args))
+	})
+}
+
+func TestInterpolateAt(t *testing.T) {
+	withPlaceholderStyle(common.AtPlaceholder, func() {
+		str, _, err := Interpolate("SELECT * FROM x WHERE a = @p2 AND b = @p1", []interface{}{1, 2})
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM x WHERE a = 2 AND b = 1", str)
+	})
+}
@@ -143,3 +143,48 @@ func (pd *Postgres) WriteFormattedTime(buf common.BufferWriter, t time.Time) {
 		buf.WriteString(" BC")
 	}
 }
+
+// PlaceholderStyle returns $1, $2 ... placeholders.
+func (pd *Postgres) PlaceholderStyle() common.PlaceholderStyle {
+	return common.DollarPlaceholder
+}
+
+// WriteBoolLiteral writes a boolean literal.
+func (pd *Postgres) WriteBoolLiteral(buf common.BufferWriter, b bool) {
+	if b {
+		buf.WriteString(`'t'`)
+	} else {
+		buf.WriteString(`'f'`)
+	}
+}
+
+// SupportsReturning returns true, Postgres supports RETURNING.
+func (pd *Postgres) SupportsReturning() bool {
+	return true
+}
+
+// SupportsWritableCTE returns true, Postgres supports data-modifying
+// statements in WITH.
+func (pd *Postgres) SupportsWritableCTE() bool {
+	return true
+}
+
+// SupportsOnConflict returns true, Postgres supports ON CONFLICT since 9.5.
+func (pd *Postgres) SupportsOnConflict() bool {
+	return true
+}
+
+// SupportsUpdateFrom returns true, Postgres supports UPDATE ... FROM.
+func (pd *Postgres) SupportsUpdateFrom() bool {
+	return true
+}
+
+// SupportsDeleteUsing returns true, Postgres supports DELETE ... USING.
+func (pd *Postgres) SupportsDeleteUsing() bool {
+	return true
+}
+
+// RowIDColumn returns ctid, the physical location of a row.
+func (pd *Postgres) RowIDColumn() string {
+	return "ctid"
+}
@@ -0,0 +1,184 @@
+package dat
+
+import (
+	"reflect"
+	"strconv"
+
+	"gopkg.in/mgutz/dat.v1/common"
+)
+
+// Predicate is a condition which may be passed to Where and Having, and
+// nested in And, Or and Not. Expr and Eq are predicates.
+//
+//	Select("*").From("posts").Where(Or(
+//		Eq{"state": "published"},
+//		And(Gt("votes", 10), Not(IsNull("reviewed_at"))),
+//	))
+//
+// Columns are written as is, like OrderBy. A value which is a Builder is
+// inlined as a sub query.
+type Predicate interface {
+	// writePredicate writes the condition in parentheses.
+	writePredicate(d SQLDialect, buf common.BufferWriter, args *[]interface{}, pos *int64)
+}
+
+type junction struct {
+	op     string
+	preds  []Predicate
+	ifNone string
+}
+
+// And is true if all preds are true. It is true if there are none.
+func And(preds ...Predicate) Predicate {
+	return &junction{op: " AND ", preds: preds, ifNone: "(1=1)"}
+}
+
+// Or is true if any of preds is true. It is false if there are none.
+func Or(preds ...Predicate) Predicate {
+	return &junction{op: " OR ", preds: preds, ifNone: "(1=0)"}
+}
+
+func (j *junction) writePredicate(d SQLDialect, buf common.BufferWriter, args *[]interface{}, pos *int64) {
+	if len(j.preds) == 0 {
+		buf.WriteString(j.ifNone)
+		return
+	}
+	if len(j.preds) == 1 {
+		j.preds[0].writePredicate(d, buf, args, pos)
+		return
+	}
+	buf.WriteRune('(')
+	for i, p := range j.preds {
+		if i > 0 {
+			buf.WriteString(j.op)
+		}
+		p.writePredicate(d, buf, args, pos)
+	}
+	buf.WriteRune(')')
+}
+
+type notPredicate struct {
+	pred Predicate
+}
+
+// Not negates pred.
+func Not(pred Predicate) Predicate {
+	return &notPredicate{pred}
+}
+
+func (n *notPredicate) writePredicate(d SQLDialect, buf common.BufferWriter, args *[]interface{}, pos *int64) {
+	buf.WriteString("(NOT ")
+	n.pred.writePredicate(d, buf, args, pos)
+	buf.WriteRune(')')
+}
+
+// comparison is "(column op $1 ...)" where the placeholders are relative to
+// values.
+type comparison struct {
+	column string
+	op     string
+	values []interface{}
+}
+
+func (c *comparison) writePredicate(d SQLDialect, buf common.BufferWriter, args *[]interface{}, pos *int64) {
+	buf.WriteRune('(')
+	buf.WriteString(c.column)
+	remapPlaceholders(d, buf, c.op, c.values, args, pos)
+	buf.WriteRune(')')
+}
+
+func newComparison(column string, op string, values ...interface{}) *comparison {
+	if column == "" {
+		panic("predicate requires a column")
+	}
+	return &comparison{column: column, op: op, values: values}
+}
+
+// Gt is "column > value".
+func Gt(column string, value interface{}) Predicate {
+	return newComparison(column, " > $1", value)
+}
+
+// Gte is "column >= value".
+func Gte(column string, value interface{}) Predicate {
+	return newComparison(column, " >= $1", value)
+}
+
+// Lt is "column < value".
+func Lt(column string, value interface{}) Predicate {
+	return newComparison(column, " < $1", value)
+}
+
+// Lte is "column <= value".
+func Lte(column string, value interface{}) Predicate {
+	return newComparison(column, " <= $1", value)
+}
+
+// Like is "column LIKE pattern".
+func Like(column string, pattern interface{}) Predicate {
+	return newComparison(column, " LIKE $1", pattern)
+}
+
+// Between is "column BETWEEN low AND high".
+func Between(column string, low, high interface{}) Predicate {
+	return newComparison(column, " BETWEEN $1 AND $2", low, high)
+}
+
+// IsNull is "column IS NULL".
+func IsNull(column string) Predicate {
+	return newComparison(column, " IS NULL")
+}
+
+// In is "column IN (values...)" where values is a slice or a Builder. A
+// placeholder is written for each element, an empty slice is false.
+func In(column string, values interface{}) Predicate {
+	if _, ok := values.(Builder); ok {
+		return newComparison(column, " IN $1", values)
+	}
+
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		panic("In accepts only {slice, array, Builder} values")
+	}
+	if v.Len() == 0 {
+		return Or()
+	}
+
+	op := buildInOperator(v.Len())
+	elems := make([]interface{}, v.Len())
+	for i := range elems {
+		elems[i] = v.Index(i).Interface()
+	}
+	return newComparison(column, op, elems...)
+}
+
+// buildInOperator returns " IN ($1,$2,...)" for n values.
+func buildInOperator(n int) string {
+	op := " IN ("
+	for i := 1; i <= n; i++ {
+		if i > 1 {
+			op += ","
+		}
+		op += "$" + strconv.Itoa(i)
+	}
+	return op + ")"
+}
+
+func (exp *Expression) writePredicate(d SQLDialect, buf common.BufferWriter, args *[]interface{}, pos *int64) {
+	buf.WriteRune('(')
+	exp.writeRelativeArgs(d, buf, args, pos)
+	buf.WriteRune(')')
+}
+
+func (eq Eq) writePredicate(d SQLDialect, buf common.BufferWriter, args *[]interface{}, pos *int64) {
+	switch len(eq) {
+	case 0:
+		buf.WriteString("(1=1)")
+	case 1:
+		writeEqualityMapToSQL(d, buf, eq, args, false, pos)
+	default:
+		buf.WriteRune('(')
+		writeEqualityMapToSQL(d, buf, eq, args, false, pos)
+		buf.WriteRune(')')
+	}
+}
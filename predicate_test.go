@@ -0,0 +1,89 @@
+package dat
+
+import (
+	"testing"
+
+	"gopkg.in/mgutz/dat.v1/mysql"
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+func TestPredicateTree(t *testing.T) {
+	sql, args := Select("*").
+		From("posts").
+		Where("user_id = $1", 1).
+		Where(Or(
+			Eq{"state": "published"},
+			And(Gt("votes", 10), Lte("votes", 100), Not(IsNull("reviewed_at"))),
+			Expr("title = $1 OR title = $2", "a", "b"),
+		)).
+		Where(Between("created_at", 5, 10)).
+		Where(Like("lower(title)", "%go%")).
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT * FROM posts
+		WHERE (user_id = $1)
+			AND (("state" = $2)
+				OR ((votes > $3) AND (votes <= $4) AND (NOT (reviewed_at IS NULL)))
+				OR (title = $5 OR title = $6))
+			AND (created_at BETWEEN $7 AND $8)
+			AND (lower(title) LIKE $9)`), stripWS(sql))
+	assert.Exactly(t, []interface{}{1, "published", 10, 100, "a", "b", 5, 10, "%go%"}, args)
+}
+
+func TestPredicateIn(t *testing.T) {
+	sql, args := Select("*").
+		From("posts").
+		Where(In("id", []int{1, 2, 3})).
+		Where(Not(In("state", []string{"spam"}))).
+		Where(In("user_id", Select("id").From("people").Where("name = $1", "mario"))).
+		Having(Gte("count(*)", 2)).
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT * FROM posts
+		WHERE (id IN ($1,$2,$3))
+			AND (NOT (state IN ($4)))
+			AND (user_id IN (SELECT id FROM people WHERE (name = $5)))
+		HAVING (count(*) >= $6)`), stripWS(sql))
+	assert.Exactly(t, []interface{}{1, 2, 3, "spam", "mario", 2}, args)
+
+	sql, args = Select("*").From("posts").Where(In("id", []int{})).ToSQL()
+	assert.Equal(t, "SELECT * FROM posts WHERE (1=0)", sql)
+	assert.Nil(t, args)
+
+	assert.Panics(t, func() {
+		In("id", 1)
+	})
+}
+
+func TestPredicateEmpty(t *testing.T) {
+	sql, _ := Select("*").From("posts").Where(And()).Where(Or()).Where(Or(Eq{})).ToSQL()
+	assert.Equal(t, "SELECT * FROM posts WHERE (1=1) AND (1=0) AND (1=1)", sql)
+}
+
+func TestPredicateQuestion(t *testing.T) {
+	sql, args := Select("*").
+		From("posts").
+		Where(Or(Lt("votes", 0), In("id", []int{4, 5}))).
+		Where("user_id = $1", 1).
+		SetDialect(mysql.New()).
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT * FROM posts
+		WHERE ((votes < ?) OR (id IN (?,?))) AND (user_id = ?)`), stripWS(sql))
+	assert.Exactly(t, []interface{}{0, 4, 5, 1}, args)
+}
+
+func TestPredicateUpdateDelete(t *testing.T) {
+	sql, args := Update("posts").
+		Set("state", "archived").
+		Where(Or(Lt("created_at", 100), IsNull("user_id"))).
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		UPDATE "posts" SET "state" = $1
+		WHERE ((created_at < $2) OR (user_id IS NULL))`), stripWS(sql))
+	assert.Exactly(t, []interface{}{"archived", 100}, args)
+
+	sql, args = DeleteFrom("posts").Where(In("id", []int64{7, 8})).ToSQL()
+	assert.Equal(t, stripWS(`DELETE FROM posts WHERE (id IN ($1,$2))`), stripWS(sql))
+	assert.Exactly(t, []interface{}{int64(7), int64(8)}, args)
+}
@@ -1,10 +1,13 @@
 package dat
 
+import "gopkg.in/mgutz/dat.v1/common"
+
 // RawBuilder builds SQL from raw SQL.
 type RawBuilder struct {
 	Execer
 
 	isInterpolated bool
+	dialect        SQLDialect
 	sql            string
 	args           []interface{}
 }
@@ -14,7 +17,18 @@ func NewRawBuilder(sql string, args ...interface{}) *RawBuilder {
 	return &RawBuilder{sql: sql, args: args, isInterpolated: EnableInterpolation}
 }
 
-// ToSQL implements builder interface
+// ToSQL implements builder interface. $n placeholders are written in the
+// dialect's placeholder style and builder args are inlined.
 func (b *RawBuilder) ToSQL() (string, []interface{}) {
-	return b.sql, b.args
+	d := b.sqlDialect()
+	if d.PlaceholderStyle() == common.DollarPlaceholder && !hasBuilderValues(b.args) {
+		return b.sql, b.args
+	}
+
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+	var args []interface{}
+	var pos int64 = 1
+	remapPlaceholders(d, buf, b.sql, b.args, &args, &pos)
+	return buf.String(), args
 }
@@ -54,6 +54,10 @@ func (scope *MapScope) mergeClone(fields M) *MapScope {
 
 // ToSQL converts this scope's SQL to SQL and args.
 func (scope *MapScope) ToSQL(table string) (string, []interface{}) {
+	return scope.toSQL(Dialect, table)
+}
+
+func (scope *MapScope) toSQL(d SQLDialect, table string) (string, []interface{}) {
 	buf := bufPool.Get()
 	defer bufPool.Put(buf)
 
@@ -62,7 +66,7 @@ func (scope *MapScope) ToSQL(table string) (string, []interface{}) {
 	sql := reField.ReplaceAllStringFunc(scope.SQL, func(found string) string {
 		buf.Reset()
 		if found == ":TABLE" {
-			Dialect.WriteIdentifier(buf, table)
+			d.WriteIdentifier(buf, table)
 			return buf.String()
 		}
 		if args == nil {
@@ -70,7 +74,7 @@ func (scope *MapScope) ToSQL(table string) (string, []interface{}) {
 		}
 		field := found[1:]
 		args = append(args, scope.Fields[field])
-		writePlaceholder(buf, n)
+		writePlaceholder(d, buf, n)
 		n++
 		return buf.String()
 	})
@@ -78,14 +82,22 @@ func (scope *MapScope) ToSQL(table string) (string, []interface{}) {
 	return sql, args
 }
 
-// escapeScopeTable escapes :TABLE in sql using Dialect.WriteIdentifer.
-func escapeScopeTable(sql string, table string) string {
+// scopeToSQL converts scope to SQL and args, rendering a MapScope with d.
+func scopeToSQL(d SQLDialect, scope Scope, table string) (string, []interface{}) {
+	if ms, ok := scope.(*MapScope); ok {
+		return ms.toSQL(d, table)
+	}
+	return scope.ToSQL(table)
+}
+
+// escapeScopeTable escapes :TABLE in sql using d.WriteIdentifer.
+func escapeScopeTable(d SQLDialect, sql string, table string) string {
 	if !strings.Contains(sql, ":TABLE") {
 		return sql
 	}
 
 	var buf bytes.Buffer
-	Dialect.WriteIdentifier(&buf, table)
+	d.WriteIdentifier(&buf, table)
 	quoted := buf.String()
 	return strings.Replace(sql, ":TABLE", quoted, -1)
 }
@@ -0,0 +1,223 @@
+package dat
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// seekColumn is an order column of keyset pagination.
+type seekColumn struct {
+	column     string
+	desc       bool
+	nullsFirst bool
+}
+
+// parseSeekColumn parses "column [ASC|DESC] [NULLS FIRST|LAST]". NULLs sort
+// last in ascending order unless specified, as in Postgres.
+func parseSeekColumn(s string) seekColumn {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		panic("SeekAfter requires order columns")
+	}
+
+	var c seekColumn
+	explicitNulls := false
+	if n := len(fields); n >= 3 && strings.EqualFold(fields[n-2], "NULLS") {
+		c.nullsFirst = strings.EqualFold(fields[n-1], "FIRST")
+		explicitNulls = true
+		fields = fields[:n-2]
+	}
+	if n := len(fields); n >= 2 {
+		if strings.EqualFold(fields[n-1], "DESC") {
+			c.desc = true
+			fields = fields[:n-1]
+		} else if strings.EqualFold(fields[n-1], "ASC") {
+			fields = fields[:n-1]
+		}
+	}
+	if !explicitNulls {
+		c.nullsFirst = c.desc
+	}
+	c.column = strings.Join(fields, " ")
+	return c
+}
+
+// field returns the name of the struct field of the column, without a
+// table qualifier or quotes.
+func (c seekColumn) field() string {
+	name := c.column
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.Trim(name, "\"`")
+}
+
+// after is true for rows after a row whose value of the column is v. It is
+// nil if there are none.
+func (c seekColumn) after(v interface{}) Predicate {
+	if v == nil {
+		if c.nullsFirst {
+			return Not(IsNull(c.column))
+		}
+		return nil
+	}
+
+	op := " > $1"
+	if c.desc {
+		op = " < $1"
+	}
+	pred := newComparison(c.column, op, v)
+	if c.nullsFirst {
+		return pred
+	}
+	return Or(pred, IsNull(c.column))
+}
+
+// equal is true for rows whose value of the column is v.
+func (c seekColumn) equal(v interface{}) Predicate {
+	if v == nil {
+		return IsNull(c.column)
+	}
+	return newComparison(c.column, " = $1", v)
+}
+
+// seekPredicate is true for rows after values in the order of columns.
+//
+//	(a > $1) OR ((a = $1) AND (b > $2)) OR ...
+func seekPredicate(columns []seekColumn, values []interface{}) Predicate {
+	var terms, equal []Predicate
+	for i, c := range columns {
+		v := cursorValue(values[i])
+		if after := c.after(v); after != nil {
+			n := len(equal)
+			terms = append(terms, And(append(equal[:n:n], after)...))
+		}
+		equal = append(equal, c.equal(v))
+	}
+	return Or(terms...)
+}
+
+// SeekAfter orders the statement by orderColumns, each of which is
+// "column [ASC|DESC] [NULLS FIRST|LAST]", and selects the rows after the row
+// with lastValues. Without lastValues the first page is selected. This is
+// keyset pagination, the last column should be unique. The order columns
+// precede those of OrderBy, calling SeekAfter again replaces them.
+//
+//	order := []string{"created_at DESC", "id"}
+//	values, err := DecodeCursor(token, len(order))
+//	b := DB.Select("*").From("posts").SeekAfter(order, values...).Limit(20)
+//	err = b.QueryStructs(&posts)
+//	next, err := b.NextCursor(&posts)
+func (b *SelectBuilder) SeekAfter(orderColumns []string, lastValues ...interface{}) *SelectBuilder {
+	if len(orderColumns) == 0 {
+		panic("SeekAfter requires order columns")
+	}
+	if len(lastValues) > 0 && len(lastValues) != len(orderColumns) {
+		panic("SeekAfter requires a value for each order column")
+	}
+
+	// drop the order columns of a previous call, which are first
+	orderBys := b.orderBys[len(b.seekColumns):]
+	b.orderBys = nil
+	b.seekColumns = nil
+	b.seekFragment = nil
+	for _, s := range orderColumns {
+		b.seekColumns = append(b.seekColumns, parseSeekColumn(s))
+		b.OrderBy(s)
+	}
+	b.orderBys = append(b.orderBys, orderBys...)
+	if len(lastValues) > 0 {
+		b.seekFragment = newWhereFragment(seekPredicate(b.seekColumns, lastValues), nil)
+	}
+	return b
+}
+
+// NextCursor returns the cursor for SeekAfter of the last row in dest, a
+// pointer to a slice of structs loaded by QueryStructs. The cursor is empty
+// if dest is empty.
+func (b *SelectBuilder) NextCursor(dest interface{}) (string, error) {
+	if len(b.seekColumns) == 0 {
+		panic("NextCursor requires SeekAfter")
+	}
+
+	rows := reflect.Indirect(reflect.ValueOf(dest))
+	if rows.Kind() != reflect.Slice {
+		panic("NextCursor requires a pointer to a slice of structs")
+	}
+	if rows.Len() == 0 {
+		return "", nil
+	}
+
+	last := reflect.Indirect(rows.Index(rows.Len() - 1))
+	fields := make([]string, len(b.seekColumns))
+	for i, c := range b.seekColumns {
+		fields[i] = c.field()
+	}
+	values, err := valuesFor(last.Type(), last, fields)
+	if err != nil {
+		return "", err
+	}
+	return EncodeCursor(values...)
+}
+
+// EncodeCursor encodes the values of the order columns of a row as an
+// opaque token, see SeekAfter.
+func EncodeCursor(values ...interface{}) (string, error) {
+	vals := make([]interface{}, len(values))
+	for i, v := range values {
+		vals[i] = cursorValue(v)
+	}
+	b, err := json.Marshal(vals)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor decodes a token created by EncodeCursor with n values, one for
+// each order column of SeekAfter. An empty token has no values, which is the
+// first page. Times are decoded as strings. The token is client supplied,
+// ErrInvalidCursor is returned unless it has n scalar values.
+func DecodeCursor(cursor string, n int) ([]interface{}, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var values []interface{}
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.UseNumber()
+	if err := dec.Decode(&values); err != nil || len(values) != n || dec.More() {
+		return nil, ErrInvalidCursor
+	}
+	for i, v := range values {
+		switch v := v.(type) {
+		case nil, bool, string:
+		case json.Number:
+			if values[i], err = v.Int64(); err != nil {
+				if values[i], err = v.Float64(); err != nil {
+					return nil, ErrInvalidCursor
+				}
+			}
+		default:
+			return nil, ErrInvalidCursor
+		}
+	}
+	return values, nil
+}
+
+// cursorValue returns the value of v for the database.
+func cursorValue(v interface{}) interface{} {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if val, err := valuer.Value(); err == nil {
+			return val
+		}
+	}
+	return v
+}
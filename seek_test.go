@@ -0,0 +1,159 @@
+package dat
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+func TestSeekAfterFirstPage(t *testing.T) {
+	sql, args := Select("*").From("posts").SeekAfter([]string{"created_at DESC", "id"}).Limit(10).ToSQL()
+	assert.Equal(t, "SELECT * FROM posts ORDER BY created_at DESC, id LIMIT 10", sql)
+	assert.Nil(t, args)
+}
+
+func TestSeekAfterSingle(t *testing.T) {
+	sql, args := Select("*").From("posts").SeekAfter([]string{"id"}, 10).Limit(10).ToSQL()
+	assert.Equal(t, "SELECT * FROM posts WHERE ((id > $1) OR (id IS NULL)) ORDER BY id LIMIT 10", sql)
+	assert.Exactly(t, []interface{}{10}, args)
+
+	sql, args = Select("*").From("posts").SeekAfter([]string{"id ASC NULLS FIRST"}, 10).ToSQL()
+	assert.Equal(t, "SELECT * FROM posts WHERE (id > $1) ORDER BY id ASC NULLS FIRST", sql)
+	assert.Exactly(t, []interface{}{10}, args)
+}
+
+func TestSeekAfterMixed(t *testing.T) {
+	sql, args := Select("*").
+		From("posts p").
+		Where("p.state = $1", "published").
+		SeekAfter([]string{"p.created_at DESC", "title asc nulls first", "p.id"}, 100, "b", 7).
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT * FROM posts p
+		WHERE (p.state = $1)
+			AND ((p.created_at < $2)
+				OR ((p.created_at = $3) AND (title > $4))
+				OR ((p.created_at = $5) AND (title = $6) AND ((p.id > $7) OR (p.id IS NULL))))
+		ORDER BY p.created_at DESC, title asc nulls first, p.id`), stripWS(sql))
+	assert.Exactly(t, []interface{}{"published", 100, 100, "b", 100, "b", 7}, args)
+}
+
+func TestSeekAfterNulls(t *testing.T) {
+	// NULL sorts last ascending, no row follows it but for the tie breaker
+	sql, args := Select("*").From("posts").SeekAfter([]string{"published_at", "id"}, nil, 7).ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT * FROM posts
+		WHERE ((published_at IS NULL) AND ((id > $1) OR (id IS NULL)))
+		ORDER BY published_at, id`), stripWS(sql))
+	assert.Exactly(t, []interface{}{7}, args)
+
+	// NULL sorts first descending, all non NULL rows follow it
+	sql, args = Select("*").From("posts").SeekAfter([]string{"published_at DESC", "id"}, NullTime{}, 7).ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT * FROM posts
+		WHERE ((NOT (published_at IS NULL)) OR ((published_at IS NULL) AND ((id > $1) OR (id IS NULL))))
+		ORDER BY published_at DESC, id`), stripWS(sql))
+	assert.Exactly(t, []interface{}{7}, args)
+}
+
+func TestSeekAfterOrderBy(t *testing.T) {
+	b := Select("*").
+		From("posts").
+		OrderBy("title").
+		SeekAfter([]string{"created_at DESC", "id"}, 100, 7).
+		SeekAfter([]string{"id"}, 10)
+	sql, args := b.ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT * FROM posts
+		WHERE ((id > $1) OR (id IS NULL))
+		ORDER BY id, title`), stripWS(sql))
+	assert.Exactly(t, []interface{}{10}, args)
+}
+
+func TestSeekAfterInvalid(t *testing.T) {
+	assert.Panics(t, func() {
+		Select("*").From("posts").SeekAfter(nil)
+	})
+	assert.Panics(t, func() {
+		Select("*").From("posts").SeekAfter([]string{"a", "b"}, 1)
+	})
+	assert.Panics(t, func() {
+		Select("*").From("posts").NextCursor(&[]someRecord{})
+	})
+}
+
+func TestCursor(t *testing.T) {
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	token, err := EncodeCursor(ts, int64(42), "x", nil, 1.5, NullString{})
+	assert.NoError(t, err)
+
+	values, err := DecodeCursor(token, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"2020-01-02T03:04:05.000000006Z", int64(42), "x", nil, 1.5, nil}, values)
+
+	values, err = DecodeCursor("", 6)
+	assert.NoError(t, err)
+	assert.Nil(t, values)
+
+	_, err = DecodeCursor("!!", 1)
+	assert.Equal(t, ErrInvalidCursor, err)
+	_, err = DecodeCursor("e30", 1)
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestCursorTampered(t *testing.T) {
+	token := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	// the wrong number of values does not reach SeekAfter
+	for _, n := range []int{1, 3} {
+		_, err := DecodeCursor(token(`[1,2]`), n)
+		assert.Equal(t, ErrInvalidCursor, err)
+	}
+	for _, s := range []string{`[]`, `[1,{"a":1}]`, `[1,[2]]`, `[1,2][3]`, `[1,1e999]`} {
+		_, err := DecodeCursor(token(s), 2)
+		assert.Equal(t, ErrInvalidCursor, err, s)
+	}
+
+	values, err := DecodeCursor(token(`[1,true]`), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(1), true}, values)
+}
+
+func TestNextCursor(t *testing.T) {
+	b := Select("*").From("a").SeekAfter([]string{"a.user_id DESC", `"something_id"`})
+
+	token, err := b.NextCursor(&[]someRecord{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", token)
+
+	rows := []*someRecord{{1, 88, false}, {2, 99, true}}
+	token, err = b.NextCursor(&rows)
+	assert.NoError(t, err)
+	values, err := DecodeCursor(token, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(99), int64(2)}, values)
+
+	sql, args := Select("*").From("a").SeekAfter([]string{"a.user_id DESC", `"something_id"`}, values...).ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT * FROM a
+		WHERE ((a.user_id < $1) OR ((a.user_id = $2) AND (("something_id" > $3) OR ("something_id" IS NULL))))
+		ORDER BY a.user_id DESC, "something_id"`), stripWS(sql))
+	assert.Exactly(t, []interface{}{int64(99), int64(99), int64(2)}, args)
+
+	_, err = Select("*").From("a").SeekAfter([]string{"missing"}).NextCursor(&rows)
+	assert.Error(t, err)
+}
+
+func TestSelectDocSeekAfter(t *testing.T) {
+	sql, args := SelectDoc("id").From("posts").SeekAfter([]string{"id DESC"}, 5).Limit(2).ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT row_to_json(dat__item.*)
+		FROM (
+			SELECT id FROM posts WHERE (id < $1) ORDER BY id DESC LIMIT 2
+		) as dat__item`), stripWS(sql))
+	assert.Exactly(t, []interface{}{5}, args)
+}
@@ -1,5 +1,11 @@
 package dat
 
+import (
+	"strings"
+
+	"gopkg.in/mgutz/dat.v1/common"
+)
+
 // SelectBuilder contains the clauses for a SELECT statement
 type SelectBuilder struct {
 	Execer
@@ -7,9 +13,13 @@ type SelectBuilder struct {
 	isDistinct      bool
 	distinctColumns []string
 	isInterpolated  bool
-	columns         []string
+	dialect         SQLDialect
+	with            withClause
+	columns         []*whereFragment
 	fors            []string
 	table           string
+	fromSub         Builder
+	joins           []*joinClause
 	whereFragments  []*whereFragment
 	groupBys        []string
 	havingFragments []*whereFragment
@@ -19,6 +29,8 @@ type SelectBuilder struct {
 	offsetCount     uint64
 	offsetValid     bool
 	scope           Scope
+	seekColumns     []seekColumn
+	seekFragment    *whereFragment
 }
 
 // NewSelectBuilder creates a new SelectBuilder for the given columns
@@ -27,7 +39,31 @@ func NewSelectBuilder(columns ...string) *SelectBuilder {
 		logger.Error("Select requires 1 or more columns")
 		return nil
 	}
-	return &SelectBuilder{columns: columns, isInterpolated: EnableInterpolation}
+	return &SelectBuilder{columns: columnFragments(nil, columns), isInterpolated: EnableInterpolation}
+}
+
+// Clone returns a deep copy of the builder. Clauses added to the clone do
+// not change b, so a base query can be shared and specialized.
+func (b *SelectBuilder) Clone() *SelectBuilder {
+	clone := *b
+	clone.distinctColumns = cloneStrings(b.distinctColumns)
+	clone.with = b.with.clone()
+	clone.columns = cloneFragments(b.columns)
+	clone.fors = cloneStrings(b.fors)
+	if b.fromSub != nil {
+		clone.fromSub = cloneBuilder(b.fromSub)
+	}
+	clone.joins = nil
+	for _, j := range b.joins {
+		clone.joins = append(clone.joins, j.clone())
+	}
+	clone.whereFragments = cloneFragments(b.whereFragments)
+	clone.groupBys = cloneStrings(b.groupBys)
+	clone.havingFragments = cloneFragments(b.havingFragments)
+	clone.orderBys = cloneFragments(b.orderBys)
+	clone.seekColumns = append([]seekColumn(nil), b.seekColumns...)
+	clone.Execer = cloneExecer(b.Execer, &clone)
+	return &clone
 }
 
 // Columns adds additional select columns to the builder.
@@ -36,7 +72,19 @@ func (b *SelectBuilder) Columns(columns ...string) *SelectBuilder {
 		logger.Error("Select requires 1 or more columns")
 		return nil
 	}
-	b.columns = append(b.columns, columns...)
+	b.columns = columnFragments(b.columns, columns)
+	return b
+}
+
+// ColumnExpr adds a select column expression. Placeholders in sql are
+// relative to args.
+//
+//	Select("id").ColumnExpr("ts_rank(doc, plainto_tsquery($1)) AS rank", q)
+func (b *SelectBuilder) ColumnExpr(sql string, args ...interface{}) *SelectBuilder {
+	if sql == "" {
+		panic("ColumnExpr requires an expression")
+	}
+	b.columns = append(b.columns, &whereFragment{Condition: sql, Values: args})
 	return b
 }
 
@@ -53,9 +101,33 @@ func (b *SelectBuilder) DistinctOn(columns ...string) *SelectBuilder {
 	return b
 }
 
+// With adds a common table expression to the WITH clause of the statement.
+// sqlOrBuilder is a Builder or a SQL string with args.
+func (b *SelectBuilder) With(name string, sqlOrBuilder interface{}, args ...interface{}) *SelectBuilder {
+	b.with.add(name, false, sqlOrBuilder, args)
+	return b
+}
+
+// WithRecursive adds a common table expression which may reference itself
+// and marks the WITH clause RECURSIVE.
+func (b *SelectBuilder) WithRecursive(name string, sqlOrBuilder interface{}, args ...interface{}) *SelectBuilder {
+	b.with.add(name, true, sqlOrBuilder, args)
+	return b
+}
+
 // From sets the table to SELECT FROM. JOINs may also be defined here.
 func (b *SelectBuilder) From(from string) *SelectBuilder {
 	b.table = from
+	b.fromSub = nil
+	return b
+}
+
+// FromSub sets a sub query to SELECT FROM as alias.
+//
+//	Select("t.id").FromSub(Select("id").From("posts").Where("user_id = $1", 1), "t")
+func (b *SelectBuilder) FromSub(sub Builder, alias string) *SelectBuilder {
+	b.table = alias
+	b.fromSub = sub
 	return b
 }
 
@@ -79,6 +151,53 @@ func (b *SelectBuilder) Scope(sql string, args ...interface{}) *SelectBuilder {
 	return b
 }
 
+// Join appends an INNER JOIN of table, a table name or Builder, with an
+// optional alias. Placeholders in on are relative to args.
+//
+//	Select("u.name", "p.title").
+//		From("users u").
+//		Join("posts", "p", "p.user_id = u.id AND p.state = $1", "published")
+func (b *SelectBuilder) Join(table interface{}, alias string, on string, args ...interface{}) *SelectBuilder {
+	b.joins = append(b.joins, newJoinClause("INNER JOIN", false, table, alias, on, args))
+	return b
+}
+
+// LeftJoin appends a LEFT JOIN, see Join.
+func (b *SelectBuilder) LeftJoin(table interface{}, alias string, on string, args ...interface{}) *SelectBuilder {
+	b.joins = append(b.joins, newJoinClause("LEFT JOIN", false, table, alias, on, args))
+	return b
+}
+
+// RightJoin appends a RIGHT JOIN, see Join.
+func (b *SelectBuilder) RightJoin(table interface{}, alias string, on string, args ...interface{}) *SelectBuilder {
+	b.joins = append(b.joins, newJoinClause("RIGHT JOIN", false, table, alias, on, args))
+	return b
+}
+
+// FullJoin appends a FULL JOIN, see Join.
+func (b *SelectBuilder) FullJoin(table interface{}, alias string, on string, args ...interface{}) *SelectBuilder {
+	b.joins = append(b.joins, newJoinClause("FULL JOIN", false, table, alias, on, args))
+	return b
+}
+
+// CrossJoin appends a CROSS JOIN of table, a table name or Builder, with an
+// optional alias.
+func (b *SelectBuilder) CrossJoin(table interface{}, alias string) *SelectBuilder {
+	b.joins = append(b.joins, newJoinClause("CROSS JOIN", false, table, alias, "", nil))
+	return b
+}
+
+// JoinLateral appends an INNER JOIN LATERAL of sub, which may reference
+// columns of preceding tables. An empty on joins ON TRUE.
+//
+//	Select("u.name", "p.title").
+//		From("users u").
+//		JoinLateral(Select("title").From("posts").Where("user_id = u.id").Limit(1), "p", "")
+func (b *SelectBuilder) JoinLateral(sub Builder, alias string, on string, args ...interface{}) *SelectBuilder {
+	b.joins = append(b.joins, newJoinClause("INNER JOIN", true, sub, alias, on, args))
+	return b
+}
+
 // Where appends a WHERE clause to the statement for the given string and args
 // or map of column/value pairs
 func (b *SelectBuilder) Where(whereSQLOrMap interface{}, args ...interface{}) *SelectBuilder {
@@ -118,6 +237,35 @@ func (b *SelectBuilder) Offset(offset uint64) *SelectBuilder {
 	return b
 }
 
+// CountBuilder derives a builder which counts the rows of the statement.
+// ORDER BY, LIMIT, OFFSET, FOR and the SeekAfter condition are dropped. A
+// statement with DISTINCT, GROUP BY or HAVING is counted as a sub query. The
+// count is not cached.
+//
+//	SELECT count(*) FROM (SELECT DISTINCT ...) AS dat__count
+func (b *SelectBuilder) CountBuilder() *SelectBuilder {
+	c := b.Clone()
+	uncache(c.Execer)
+	c.orderBys = nil
+	c.seekColumns = nil
+	c.seekFragment = nil
+	c.limitValid = false
+	c.offsetValid = false
+	c.fors = nil
+
+	if !c.isDistinct && len(c.groupBys) == 0 && len(c.havingFragments) == 0 {
+		c.columns = columnFragments(nil, []string{"count(*)"})
+		return c
+	}
+
+	count := NewSelectBuilder("count(*)").FromSub(c, "dat__count")
+	count.isInterpolated = b.isInterpolated
+	count.dialect = b.dialect
+	count.Execer = cloneExecer(b.Execer, count)
+	uncache(count.Execer)
+	return count
+}
+
 // Paginate sets LIMIT/OFFSET for the statement based on the given page/perPage
 // Assumes page/perPage are valid. Page and perPage must be >= 1
 func (b *SelectBuilder) Paginate(page, perPage uint64) *SelectBuilder {
@@ -129,6 +277,7 @@ func (b *SelectBuilder) Paginate(page, perPage uint64) *SelectBuilder {
 // ToSQL serialized the SelectBuilder to a SQL string
 // It returns the string with placeholders and a slice of query arguments
 func (b *SelectBuilder) ToSQL() (string, []interface{}) {
+	d := b.sqlDialect()
 	if len(b.columns) == 0 {
 		panic("no columns specified")
 	}
@@ -139,7 +288,9 @@ func (b *SelectBuilder) ToSQL() (string, []interface{}) {
 	buf := bufPool.Get()
 	defer bufPool.Put(buf)
 	var args []interface{}
+	var placeholderStartPos int64 = 1
 
+	b.with.writeSQL(d, buf, &args, &placeholderStartPos)
 	buf.WriteString("SELECT ")
 
 	if b.isDistinct {
@@ -157,32 +308,10 @@ func (b *SelectBuilder) ToSQL() (string, []interface{}) {
 		}
 	}
 
-	for i, s := range b.columns {
-		if i > 0 {
-			buf.WriteString(", ")
-		}
-		buf.WriteString(s)
-	}
+	writeCommaFragmentsToSQL(d, buf, b.columns, &args, &placeholderStartPos)
 
-	buf.WriteString(" FROM ")
-	buf.WriteString(b.table)
-
-	var placeholderStartPos int64 = 1
-	if b.scope != nil {
-		var where string
-		sql, args2 := b.scope.ToSQL(b.table)
-		sql, where = splitWhere(sql)
-		buf.WriteString(sql)
-		if where != "" {
-			fragment := newWhereFragment(where, args2)
-			b.whereFragments = append(b.whereFragments, fragment)
-		}
-	}
-
-	if len(b.whereFragments) > 0 {
-		buf.WriteString(" WHERE ")
-		writeAndFragmentsToSQL(buf, b.whereFragments, &args, &placeholderStartPos)
-	}
+	b.writeFrom(d, buf, &args, &placeholderStartPos)
+	b.writeWhere(d, buf, &args, &placeholderStartPos)
 
 	if len(b.groupBys) > 0 {
 		buf.WriteString(" GROUP BY ")
@@ -196,12 +325,12 @@ func (b *SelectBuilder) ToSQL() (string, []interface{}) {
 
 	if len(b.havingFragments) > 0 {
 		buf.WriteString(" HAVING ")
-		writeAndFragmentsToSQL(buf, b.havingFragments, &args, &placeholderStartPos)
+		writeAndFragmentsToSQL(d, buf, b.havingFragments, &args, &placeholderStartPos)
 	}
 
 	if len(b.orderBys) > 0 {
 		buf.WriteString(" ORDER BY ")
-		writeCommaFragmentsToSQL(buf, b.orderBys, &args, &placeholderStartPos)
+		writeCommaFragmentsToSQL(d, buf, b.orderBys, &args, &placeholderStartPos)
 	}
 
 	if b.limitValid {
@@ -225,3 +354,71 @@ func (b *SelectBuilder) ToSQL() (string, []interface{}) {
 
 	return buf.String(), args
 }
+
+// writeFrom writes the FROM clause, a table or a sub query with its alias,
+// and the joins.
+func (b *SelectBuilder) writeFrom(d SQLDialect, buf common.BufferWriter, args *[]interface{}, pos *int64) {
+	buf.WriteString(" FROM ")
+	if b.fromSub != nil {
+		sql, subArgs := builderSQL(d, b.fromSub)
+		buf.WriteRune('(')
+		remapPlaceholders(d, buf, sql, subArgs, args, pos)
+		buf.WriteString(") AS ")
+	}
+	buf.WriteString(b.table)
+
+	for _, j := range b.joins {
+		j.writeSQL(d, buf, args, pos)
+	}
+}
+
+// writeWhere writes the joins of the scope and the WHERE clause, which
+// includes the conditions of the scope and SeekAfter.
+func (b *SelectBuilder) writeWhere(d SQLDialect, buf common.BufferWriter, args *[]interface{}, pos *int64) {
+	whereFragments := b.whereFragments
+	if b.scope != nil {
+		sql, scopeArgs := scopeToSQL(d, b.scope, b.table)
+		sql, where := splitWhere(sql)
+		buf.WriteString(sql)
+		if where != "" {
+			// append to a copy, ToSQL does not modify the builder
+			n := len(whereFragments)
+			whereFragments = append(whereFragments[:n:n], newWhereFragment(where, scopeArgs))
+		}
+	}
+	if b.seekFragment != nil {
+		n := len(whereFragments)
+		whereFragments = append(whereFragments[:n:n], b.seekFragment)
+	}
+
+	if len(whereFragments) > 0 {
+		buf.WriteString(" WHERE ")
+		writeAndFragmentsToSQL(d, buf, whereFragments, args, pos)
+	}
+}
+
+// columnFragments appends columns, which have no args, to fragments.
+func columnFragments(fragments []*whereFragment, columns []string) []*whereFragment {
+	for _, c := range columns {
+		fragments = append(fragments, &whereFragment{Condition: c})
+	}
+	return fragments
+}
+
+// Over returns an OVER clause for a window function column. Empty arguments
+// are omitted.
+//
+//	Select("id").ColumnExpr("row_number() " + Over("user_id", "created_at DESC", ""))
+func Over(partitionBy, orderBy, frame string) string {
+	var parts []string
+	if partitionBy != "" {
+		parts = append(parts, "PARTITION BY "+partitionBy)
+	}
+	if orderBy != "" {
+		parts = append(parts, "ORDER BY "+orderBy)
+	}
+	if frame != "" {
+		parts = append(parts, frame)
+	}
+	return "OVER (" + strings.Join(parts, " ") + ")"
+}
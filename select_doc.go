@@ -20,6 +20,17 @@ func NewSelectDocBuilder(columns ...string) *SelectDocBuilder {
 	return &SelectDocBuilder{SelectBuilder: sb, isParent: true}
 }
 
+// Clone returns a deep copy of the builder. Clauses added to the clone do
+// not change b, so a base query can be shared and specialized.
+func (b *SelectDocBuilder) Clone() *SelectDocBuilder {
+	clone := *b
+	clone.SelectBuilder = b.SelectBuilder.Clone()
+	clone.subQueries = append([]*subInfo(nil), b.subQueries...)
+	clone.subQueriesOne = append([]*subInfo(nil), b.subQueriesOne...)
+	clone.Execer = cloneExecer(b.Execer, &clone)
+	return &clone
+}
+
 // InnerSQL sets the SQL after the SELECT (columns...) statement
 func (b *SelectDocBuilder) InnerSQL(sql string, a ...interface{}) *SelectDocBuilder {
 	b.innerSQL = Expr(sql, a...)
@@ -65,6 +76,7 @@ func (b *SelectDocBuilder) One(column string, sqlOrBuilder interface{}, a ...int
 // ToSQL serialized the SelectBuilder to a SQL string
 // It returns the string with placeholders and a slice of query arguments
 func (b *SelectDocBuilder) ToSQL() (string, []interface{}) {
+	d := b.sqlDialect()
 	if len(b.columns) == 0 {
 		panic("no columns specified")
 	}
@@ -77,6 +89,8 @@ func (b *SelectDocBuilder) ToSQL() (string, []interface{}) {
 	var args []interface{}
 	var placeholderStartPos int64 = 1
 
+	b.with.writeSQL(d, buf, &args, &placeholderStartPos)
+
 	/*
 		SELECT
 			row_to_json(item.*)
@@ -118,12 +132,7 @@ func (b *SelectDocBuilder) ToSQL() (string, []interface{}) {
 		}
 	}
 
-	for i, s := range b.columns {
-		if i > 0 {
-			buf.WriteString(", ")
-		}
-		buf.WriteString(s)
-	}
+	writeCommaFragmentsToSQL(d, buf, b.columns, &args, &placeholderStartPos)
 
 	/*
 		(
@@ -138,45 +147,29 @@ func (b *SelectDocBuilder) ToSQL() (string, []interface{}) {
 		buf.WriteString(", (SELECT array_agg(dat__")
 		buf.WriteString(sub.alias)
 		buf.WriteString(".*) FROM (")
-		sub.WriteRelativeArgs(buf, &args, &placeholderStartPos)
+		sub.writeRelativeArgs(d, buf, &args, &placeholderStartPos)
 		buf.WriteString(") AS dat__")
 		buf.WriteString(sub.alias)
 		buf.WriteString(") AS ")
-		Dialect.WriteIdentifier(buf, sub.alias)
+		d.WriteIdentifier(buf, sub.alias)
 	}
 
 	for _, sub := range b.subQueriesOne {
 		buf.WriteString(", (SELECT row_to_json(dat__")
 		buf.WriteString(sub.alias)
 		buf.WriteString(".*) FROM (")
-		sub.WriteRelativeArgs(buf, &args, &placeholderStartPos)
+		sub.writeRelativeArgs(d, buf, &args, &placeholderStartPos)
 		buf.WriteString(") AS dat__")
 		buf.WriteString(sub.alias)
 		buf.WriteString(") AS ")
-		Dialect.WriteIdentifier(buf, sub.alias)
+		d.WriteIdentifier(buf, sub.alias)
 	}
 
 	if b.innerSQL != nil {
-		b.innerSQL.WriteRelativeArgs(buf, &args, &placeholderStartPos)
+		b.innerSQL.writeRelativeArgs(d, buf, &args, &placeholderStartPos)
 	} else {
-		buf.WriteString(" FROM ")
-		buf.WriteString(b.table)
-
-		if b.scope != nil {
-			var where string
-			sql, args2 := b.scope.ToSQL(b.table)
-			sql, where = splitWhere(sql)
-			buf.WriteString(sql)
-			if where != "" {
-				fragment := newWhereFragment(where, args2)
-				b.whereFragments = append(b.whereFragments, fragment)
-			}
-		}
-
-		if len(b.whereFragments) > 0 {
-			buf.WriteString(" WHERE ")
-			writeAndFragmentsToSQL(buf, b.whereFragments, &args, &placeholderStartPos)
-		}
+		b.writeFrom(d, buf, &args, &placeholderStartPos)
+		b.writeWhere(d, buf, &args, &placeholderStartPos)
 
 		// if b.scope == nil {
 		// 	if len(b.whereFragments) > 0 {
@@ -184,8 +177,8 @@ func (b *SelectDocBuilder) ToSQL() (string, []interface{}) {
 		// 		writeWhereFragmentsToSql(buf, b.whereFragments, &args, &placeholderStartPos)
 		// 	}
 		// } else {
-		// 	whereFragment := newWhereFragment(b.scope.ToSQL(b.table))
-		// 	writeScopeCondition(buf, whereFragment, &args, &placeholderStartPos)
+		// 	whereFragment := newWhereFragment(scopeToSQL(d, b.scope, b.table))
+		// 	writeScopeCondition(d, buf, whereFragment, &args, &placeholderStartPos)
 		// }
 
 		if len(b.groupBys) > 0 {
@@ -200,12 +193,12 @@ func (b *SelectDocBuilder) ToSQL() (string, []interface{}) {
 
 		if len(b.havingFragments) > 0 {
 			buf.WriteString(" HAVING ")
-			writeAndFragmentsToSQL(buf, b.havingFragments, &args, &placeholderStartPos)
+			writeAndFragmentsToSQL(d, buf, b.havingFragments, &args, &placeholderStartPos)
 		}
 
 		if len(b.orderBys) > 0 {
 			buf.WriteString(" ORDER BY ")
-			writeCommaFragmentsToSQL(buf, b.orderBys, &args, &placeholderStartPos)
+			writeCommaFragmentsToSQL(d, buf, b.orderBys, &args, &placeholderStartPos)
 		}
 
 		if b.limitValid {
@@ -242,7 +235,20 @@ func (b *SelectDocBuilder) Columns(columns ...string) *SelectDocBuilder {
 		logger.Error("Select requires 1 or more columns")
 		return nil
 	}
-	b.columns = append(b.columns, columns...)
+	b.columns = columnFragments(b.columns, columns)
+	return b
+}
+
+// SeekAfter orders the statement and selects the rows after lastValues, see
+// SelectBuilder.SeekAfter.
+func (b *SelectDocBuilder) SeekAfter(orderColumns []string, lastValues ...interface{}) *SelectDocBuilder {
+	b.SelectBuilder.SeekAfter(orderColumns, lastValues...)
+	return b
+}
+
+// ColumnExpr adds a select column expression, see SelectBuilder.ColumnExpr.
+func (b *SelectDocBuilder) ColumnExpr(sql string, args ...interface{}) *SelectDocBuilder {
+	b.SelectBuilder.ColumnExpr(sql, args...)
 	return b
 }
 
@@ -259,9 +265,67 @@ func (b *SelectDocBuilder) DistinctOn(columns ...string) *SelectDocBuilder {
 	return b
 }
 
+// With adds a common table expression to the WITH clause of the statement.
+// sqlOrBuilder is a Builder or a SQL string with args.
+func (b *SelectDocBuilder) With(name string, sqlOrBuilder interface{}, args ...interface{}) *SelectDocBuilder {
+	b.with.add(name, false, sqlOrBuilder, args)
+	return b
+}
+
+// WithRecursive adds a common table expression which may reference itself
+// and marks the WITH clause RECURSIVE.
+func (b *SelectDocBuilder) WithRecursive(name string, sqlOrBuilder interface{}, args ...interface{}) *SelectDocBuilder {
+	b.with.add(name, true, sqlOrBuilder, args)
+	return b
+}
+
 // From sets the table to SELECT FROM
 func (b *SelectDocBuilder) From(from string) *SelectDocBuilder {
 	b.table = from
+	b.fromSub = nil
+	return b
+}
+
+// FromSub sets a sub query to SELECT FROM as alias.
+func (b *SelectDocBuilder) FromSub(sub Builder, alias string) *SelectDocBuilder {
+	b.table = alias
+	b.fromSub = sub
+	return b
+}
+
+// Join appends an INNER JOIN, see SelectBuilder.Join.
+func (b *SelectDocBuilder) Join(table interface{}, alias string, on string, args ...interface{}) *SelectDocBuilder {
+	b.SelectBuilder.Join(table, alias, on, args...)
+	return b
+}
+
+// LeftJoin appends a LEFT JOIN, see SelectBuilder.Join.
+func (b *SelectDocBuilder) LeftJoin(table interface{}, alias string, on string, args ...interface{}) *SelectDocBuilder {
+	b.SelectBuilder.LeftJoin(table, alias, on, args...)
+	return b
+}
+
+// RightJoin appends a RIGHT JOIN, see SelectBuilder.Join.
+func (b *SelectDocBuilder) RightJoin(table interface{}, alias string, on string, args ...interface{}) *SelectDocBuilder {
+	b.SelectBuilder.RightJoin(table, alias, on, args...)
+	return b
+}
+
+// FullJoin appends a FULL JOIN, see SelectBuilder.Join.
+func (b *SelectDocBuilder) FullJoin(table interface{}, alias string, on string, args ...interface{}) *SelectDocBuilder {
+	b.SelectBuilder.FullJoin(table, alias, on, args...)
+	return b
+}
+
+// CrossJoin appends a CROSS JOIN, see SelectBuilder.CrossJoin.
+func (b *SelectDocBuilder) CrossJoin(table interface{}, alias string) *SelectDocBuilder {
+	b.SelectBuilder.CrossJoin(table, alias)
+	return b
+}
+
+// JoinLateral appends an INNER JOIN LATERAL, see SelectBuilder.JoinLateral.
+func (b *SelectDocBuilder) JoinLateral(sub Builder, alias string, on string, args ...interface{}) *SelectDocBuilder {
+	b.SelectBuilder.JoinLateral(sub, alias, on, args...)
 	return b
 }
 
@@ -280,7 +344,7 @@ func (b *SelectDocBuilder) ScopeMap(mapScope *MapScope, m M) *SelectDocBuilder {
 // Scope uses a predefined scope in place of WHERE.
 func (b *SelectDocBuilder) Scope(sql string, args ...interface{}) *SelectDocBuilder {
 	b.scope = ScopeFunc(func(table string) (string, []interface{}) {
-		return escapeScopeTable(sql, table), args
+		return escapeScopeTable(b.sqlDialect(), sql, table), args
 	})
 	return b
 }
@@ -129,13 +129,9 @@ func TestSelectWhereMapSql(t *testing.T) {
 	assert.Equal(t, sql, quoteSQL("SELECT a FROM b WHERE (%s = $1)", "a"))
 	assert.Equal(t, args, []interface{}{1})
 
-	sql, args = Select("a").From("b").Where(map[string]interface{}{"a": 1, "b": true}).ToSQL()
-	if sql == quoteSQL("SELECT a FROM b WHERE (%s = $1) AND (%s = $2)", "a", "b") {
-		assert.Equal(t, args, []interface{}{1, true})
-	} else {
-		assert.Equal(t, sql, quoteSQL("SELECT a FROM b WHERE (%s = $1) AND (%s = $2)", "b", "a"))
-		assert.Equal(t, args, []interface{}{true, 1})
-	}
+	sql, args = Select("a").From("b").Where(map[string]interface{}{"b": true, "a": 1}).ToSQL()
+	assert.Equal(t, sql, quoteSQL("SELECT a FROM b WHERE (%s = $1) AND (%s = $2)", "a", "b"))
+	assert.Equal(t, args, []interface{}{1, true})
 
 	sql, args = Select("a").From("b").Where(map[string]interface{}{"a": nil}).ToSQL()
 	assert.Equal(t, sql, quoteSQL("SELECT a FROM b WHERE (%s IS NULL)", "a"))
@@ -168,12 +164,21 @@ func TestSelectWhereMapSql(t *testing.T) {
 }
 
 func TestSelectWhereEqSql(t *testing.T) {
-	sql, args := Select("a").From("b").Where(Eq{"a": 1, "b": []int64{1, 2, 3}}).ToSQL()
-	if sql == quoteSQL("SELECT a FROM b WHERE (%s = $1) AND (%s IN $2)", "a", "b") {
-		assert.Equal(t, args, []interface{}{1, []int64{1, 2, 3}})
-	} else {
-		assert.Equal(t, sql, quoteSQL("SELECT a FROM b WHERE (%s IN $1) AND (%s = $2)", "b", "a"))
-		assert.Equal(t, args, []interface{}{[]int64{1, 2, 3}, 1})
+	sql, args := Select("a").From("b").Where(Eq{"b": []int64{1, 2, 3}, "a": 1}).ToSQL()
+	assert.Equal(t, sql, quoteSQL("SELECT a FROM b WHERE (%s = $1) AND (%s IN $2)", "a", "b"))
+	assert.Equal(t, args, []interface{}{1, []int64{1, 2, 3}})
+}
+
+func TestSelectWhereEqDeterministic(t *testing.T) {
+	eq := Eq{}
+	for _, c := range []string{"e", "b", "d", "a", "c", "f", "h", "g"} {
+		eq[c] = c
+	}
+	first, _ := Select("a").From("b").Where(eq).ToSQL()
+	for i := 0; i < 20; i++ {
+		sql, args := Select("a").From("b").Where(eq).ToSQL()
+		assert.Equal(t, first, sql)
+		assert.Equal(t, []interface{}{"a", "b", "c", "d", "e", "f", "g", "h"}, args)
 	}
 }
 
@@ -300,3 +305,90 @@ func TestSelectFor(t *testing.T) {
 	`), stripWS(sql))
 	assert.Exactly(t, []interface{}{1000}, args)
 }
+
+func TestSelectColumnExpr(t *testing.T) {
+	sql, args := Select("id").
+		ColumnExpr("ts_rank(doc, plainto_tsquery($1)) AS rank", "cats").
+		ColumnExpr("CASE WHEN score > $1 THEN $2 ELSE $3 END AS grade", 90, "A", "B").
+		Columns("title").
+		From("posts").
+		Where("doc @@ plainto_tsquery($1)", "cats").
+		OrderBy("rank DESC").
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT id,
+			ts_rank(doc, plainto_tsquery($1)) AS rank,
+			CASE WHEN score > $2 THEN $3 ELSE $4 END AS grade,
+			title
+		FROM posts
+		WHERE (doc @@ plainto_tsquery($5))
+		ORDER BY rank DESC`), stripWS(sql))
+	assert.Exactly(t, []interface{}{"cats", 90, "A", "B", "cats"}, args)
+}
+
+func TestSelectOver(t *testing.T) {
+	assert.Equal(t, "OVER ()", Over("", "", ""))
+	assert.Equal(t, "OVER (PARTITION BY user_id)", Over("user_id", "", ""))
+	assert.Equal(t, "OVER (ORDER BY id ROWS BETWEEN 2 PRECEDING AND CURRENT ROW)",
+		Over("", "id", "ROWS BETWEEN 2 PRECEDING AND CURRENT ROW"))
+
+	sql, args := Select("id").
+		ColumnExpr("row_number() "+Over("user_id", "created_at DESC", "")+" AS rn").
+		ColumnExpr("sum(amount) FILTER (WHERE state = $1) "+Over("user_id", "", "")+" AS paid", "paid").
+		From("orders").
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT id,
+			row_number() OVER (PARTITION BY user_id ORDER BY created_at DESC) AS rn,
+			sum(amount) FILTER (WHERE state = $1) OVER (PARTITION BY user_id) AS paid
+		FROM orders`), stripWS(sql))
+	assert.Exactly(t, []interface{}{"paid"}, args)
+}
+
+func TestSelectCountBuilder(t *testing.T) {
+	b := Select("p.id", "p.title").
+		From("posts p").
+		Join("people u", "", "u.id = p.user_id").
+		Where("p.state = $1", "published").
+		OrderBy("p.id").
+		Paginate(3, 10).
+		For("UPDATE")
+	sql, args := b.CountBuilder().ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT count(*) FROM posts p INNER JOIN people u ON u.id = p.user_id
+		WHERE (p.state = $1)`), stripWS(sql))
+	assert.Exactly(t, []interface{}{"published"}, args)
+
+	// the builder is unchanged
+	sql, _ = b.ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT p.id, p.title FROM posts p INNER JOIN people u ON u.id = p.user_id
+		WHERE (p.state = $1) ORDER BY p.id LIMIT 10 OFFSET 20 FOR UPDATE`), stripWS(sql))
+
+	sql, args = Select("user_id", "count(*)").
+		From("posts").
+		Where("state = $1", "published").
+		GroupBy("user_id").
+		Having("count(*) > $1", 1).
+		OrderBy("user_id").
+		Limit(5).
+		CountBuilder().
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT count(*) FROM (
+			SELECT user_id, count(*) FROM posts WHERE (state = $1) GROUP BY user_id HAVING (count(*) > $2)
+		) AS dat__count`), stripWS(sql))
+	assert.Exactly(t, []interface{}{"published", 1}, args)
+
+	sql, _ = Select("user_id").From("posts").Distinct().CountBuilder().ToSQL()
+	assert.Equal(t, "SELECT count(*) FROM (SELECT DISTINCT user_id FROM posts) AS dat__count", sql)
+
+	// the total is not relative to the cursor
+	b = Select("*").
+		From("posts").
+		Where("state = $1", "published").
+		SeekAfter([]string{"id"}, 10)
+	sql, args = b.CountBuilder().ToSQL()
+	assert.Equal(t, "SELECT count(*) FROM posts WHERE (state = $1)", sql)
+	assert.Exactly(t, []interface{}{"published"}, args)
+}
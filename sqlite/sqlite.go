@@ -0,0 +1,104 @@
+package sqlite
+
+import (
+	"strings"
+	"time"
+
+	"gopkg.in/mgutz/dat.v1/common"
+)
+
+// timeFormat is the first of go-sqlite3's SQLiteTimestampFormats, which the
+// driver uses to store and parse time.Time values.
+const timeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// SQLite is the SQLite dialect.
+type SQLite struct{}
+
+// New returns a new SQLite dialect.
+func New() *SQLite {
+	return &SQLite{}
+}
+
+// WriteStringLiteral writes an escaped string. SQLite has no escape
+// sequences, apostrophes are doubled.
+func (sd *SQLite) WriteStringLiteral(buf common.BufferWriter, val string) {
+	buf.WriteRune('\'')
+	if strings.Contains(val, "'") {
+		buf.WriteString(strings.Replace(val, "'", "''", -1))
+	} else {
+		buf.WriteString(val)
+	}
+	buf.WriteRune('\'')
+}
+
+// WriteIdentifier writes escaped identifier.
+func (sd *SQLite) WriteIdentifier(buf common.BufferWriter, ident string) {
+	if ident == "" {
+		panic("Identifier is empty string")
+	}
+	if ident == "*" {
+		buf.WriteString(ident)
+		return
+	}
+
+	buf.WriteRune('"')
+	buf.WriteString(strings.Replace(ident, `"`, `""`, -1))
+	buf.WriteRune('"')
+}
+
+// WriteFormattedTime formats t the way go-sqlite3 stores time.Time values.
+func (sd *SQLite) WriteFormattedTime(buf common.BufferWriter, t time.Time) {
+	buf.WriteRune('\'')
+	buf.WriteString(t.Format(timeFormat))
+	buf.WriteRune('\'')
+}
+
+// PlaceholderStyle returns ? placeholders. SQLite numbers $n
+// parameters by first appearance rather than by n, so ? is used.
+func (sd *SQLite) PlaceholderStyle() common.PlaceholderStyle {
+	return common.QuestionPlaceholder
+}
+
+// WriteBoolLiteral writes a boolean literal. SQLite stores booleans as
+// integers.
+func (sd *SQLite) WriteBoolLiteral(buf common.BufferWriter, b bool) {
+	if b {
+		buf.WriteRune('1')
+	} else {
+		buf.WriteRune('0')
+	}
+}
+
+// SupportsReturning returns true, SQLite supports RETURNING since 3.35.
+func (sd *SQLite) SupportsReturning() bool {
+	return true
+}
+
+// SupportsWritableCTE returns false, SQLite only allows SELECT in WITH.
+// Upsert and Insect are written with INSERT ... ON CONFLICT instead.
+func (sd *SQLite) SupportsWritableCTE() bool {
+	return false
+}
+
+// SupportsOnConflict returns true, SQLite supports ON CONFLICT since 3.24.
+func (sd *SQLite) SupportsOnConflict() bool {
+	return true
+}
+
+// SupportsUpdateFrom returns true, SQLite supports UPDATE ... FROM since
+// 3.33.
+func (sd *SQLite) SupportsUpdateFrom() bool {
+	return true
+}
+
+// SupportsDeleteUsing returns false, DELETE with Using selects the rows
+// through rowid.
+func (sd *SQLite) SupportsDeleteUsing() bool {
+	return false
+}
+
+// RowIDColumn returns rowid. DELETE only accepts ORDER BY and LIMIT if SQLite
+// is compiled with SQLITE_ENABLE_UPDATE_DELETE_LIMIT.
+func (sd *SQLite) RowIDColumn() string {
+	return "rowid"
+}
@@ -0,0 +1,41 @@
+package sqlite
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+func TestWriteStringLiteral(t *testing.T) {
+	d := New()
+	cases := map[string]string{
+		"":           `''`,
+		"hello":      `'hello'`,
+		"it's":       `'it''s'`,
+		`back\slash`: `'back\slash'`,
+	}
+	for val, expected := range cases {
+		var buf bytes.Buffer
+		d.WriteStringLiteral(&buf, val)
+		assert.Equal(t, expected, buf.String())
+	}
+}
+
+func TestWriteIdentifier(t *testing.T) {
+	d := New()
+	var buf bytes.Buffer
+	d.WriteIdentifier(&buf, "user_name")
+	buf.WriteRune(',')
+	d.WriteIdentifier(&buf, `odd"name`)
+	assert.Equal(t, `"user_name","odd""name"`, buf.String())
+}
+
+func TestWriteFormattedTime(t *testing.T) {
+	d := New()
+	loc := time.FixedZone("PDT", -7*60*60)
+	var buf bytes.Buffer
+	d.WriteFormattedTime(&buf, time.Date(2016, 1, 2, 3, 4, 5, 600000000, loc))
+	assert.Equal(t, `'2016-01-02 03:04:05.6-07:00'`, buf.String())
+}
@@ -261,3 +261,30 @@ func TestCacheSelectQuerySliceByHash(t *testing.T) {
 		assert.Equal(t, ids, []int64{1})
 	}
 }
+
+func TestCacheClone(t *testing.T) {
+	Cache.FlushDB()
+	b := testDB.Select("id", "name").From("people").OrderBy("id")
+	b.Cache("people.clone", 1*time.Second, false)
+
+	var people []Person
+	assert.NoError(t, b.QueryStructs(&people))
+	assert.Equal(t, 6, len(people))
+
+	// the clone shares the cache id, the cached rows are returned
+	var cached []Person
+	assert.NoError(t, b.Clone().Where("false").QueryStructs(&cached))
+	assert.Equal(t, people, cached)
+
+	var count int
+	assert.NoError(t, b.CountBuilder().QueryScalar(&count))
+	assert.Equal(t, 6, count)
+
+	// a cache id hashed from the SQL is not shared
+	b = testDB.Select("id", "name").From("people").OrderBy("id")
+	b.Cache("", 1*time.Second, false)
+	var hashed, none []Person
+	assert.NoError(t, b.QueryStructs(&hashed))
+	assert.NoError(t, b.Clone().Where("false").QueryStructs(&none))
+	assert.Equal(t, 0, len(none))
+}
@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// conn is a single connection checked out of the pool. It satisfies database
+// so a timed query runs on one connection, which the driver cancels when the
+// query's context is done.
+type conn struct {
+	*sql.Conn
+	mapper     *reflectx.Mapper
+	driverName string
+}
+
+// DriverName returns the driver name of the pool the connection came from.
+func (c *conn) DriverName() string {
+	return c.driverName
+}
+
+// QueryxContext queries the connection and returns *sqlx.Rows.
+func (c *conn) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	rows, err := c.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlx.Rows{Rows: rows, Mapper: c.mapper}, nil
+}
+
+// SelectContext scans all rows into dest, which must be a pointer to a slice.
+func (c *conn) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	rows, err := c.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	// StructScan closes rows
+	return sqlx.StructScan(rows, dest)
+}
+
+// GetContext scans a single row into dest. sql.ErrNoRows is returned if
+// there are no rows.
+func (c *conn) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	rows, err := c.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+
+	if c.isScannable(reflect.TypeOf(dest)) {
+		err = rows.Scan(dest)
+	} else {
+		err = rows.StructScan(dest)
+	}
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// isScannable mirrors sqlx: a destination is scanned directly unless it is
+// a struct with mapped fields.
+func (c *conn) isScannable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(scannerType) {
+		return true
+	}
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+	return len(c.mapper.TypeMap(t).Index) == 0
+}
+
+// driverName returns the driver name of q, if known.
+func driverName(q database) string {
+	if d, ok := q.(interface {
+		DriverName() string
+	}); ok {
+		return d.DriverName()
+	}
+	return ""
+}
@@ -1,20 +1,33 @@
 package runner
 
-import "gopkg.in/mgutz/dat.v1"
+import (
+	"context"
+
+	"gopkg.in/mgutz/dat.v1"
+)
 
 // Connection is a queryable connection and represents a DB or Tx.
 type Connection interface {
 	Begin() (*Tx, error)
 	Call(sproc string, args ...interface{}) *dat.CallBuilder
 	DeleteFrom(table string) *dat.DeleteBuilder
+	Dialect() dat.SQLDialect
+	Except(builders ...dat.Builder) *dat.CompoundBuilder
 	Exec(cmd string, args ...interface{}) (*dat.Result, error)
+	ExecContext(ctx context.Context, cmd string, args ...interface{}) (*dat.Result, error)
 	ExecBuilder(b dat.Builder) error
+	ExecBuilderContext(ctx context.Context, b dat.Builder) error
 	ExecMulti(commands ...*dat.Expression) (int, error)
+	ExecMultiContext(ctx context.Context, commands ...*dat.Expression) (int, error)
 	InsertInto(table string) *dat.InsertBuilder
 	Insect(table string) *dat.InsectBuilder
+	Intersect(builders ...dat.Builder) *dat.CompoundBuilder
+	Notify(channel string, payload string) error
 	Select(columns ...string) *dat.SelectBuilder
 	SelectDoc(columns ...string) *dat.SelectDocBuilder
 	SQL(sql string, args ...interface{}) *dat.RawBuilder
+	Union(builders ...dat.Builder) *dat.CompoundBuilder
+	UnionAll(builders ...dat.Builder) *dat.CompoundBuilder
 	Update(table string) *dat.UpdateBuilder
 	Upsert(table string) *dat.UpsertBuilder
 }
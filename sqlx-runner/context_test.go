@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gopkg.in/mgutz/dat.v1"
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+func TestContextExec(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := testDB.SQL("SELECT pg_sleep(1)").WithContext(ctx).Exec()
+	assert.Error(t, err)
+
+	// test not cancelled
+	result, err := testDB.SQL("SELECT 0").WithContext(context.Background()).Exec()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), result.RowsAffected)
+}
+
+func TestContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var n int
+	err := testDB.SQL("SELECT 1").WithContext(ctx).QueryScalar(&n)
+	assert.Error(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestContextStructs(t *testing.T) {
+	var people []TimeoutPerson
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := testDB.SQL("SELECT pg_sleep(2) as na, 'timeout' as name;").WithContext(ctx).QueryStructs(&people)
+	assert.Error(t, err)
+
+	// test not cancelled
+	err = testDB.SQL("SELECT 'john' as name, 10 as age UNION ALL SELECT 'jane' as name, 11 as age").
+		WithContext(context.Background()).
+		QueryStructs(&people)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(people))
+	assert.Equal(t, "john", people[0].Name)
+}
+
+func TestContextQueryableExec(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := testDB.ExecContext(ctx, "SELECT pg_sleep(1)")
+	assert.Error(t, err)
+
+	n, err := testDB.ExecMultiContext(context.Background(), dat.Expr("SELECT 1"), dat.Expr("SELECT 2"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
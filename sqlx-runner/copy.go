@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"database/sql"
+	"reflect"
+
+	"github.com/lib/pq"
+	"gopkg.in/mgutz/dat.v1"
+)
+
+// CopyInStmt streams rows into a table with COPY FROM STDIN. Rows are sent
+// as they are added, Close completes the copy.
+type CopyInStmt struct {
+	stmt    *sql.Stmt
+	columns []string
+}
+
+// CopyIn starts a COPY of rows into columns of table. The copy must be
+// completed with Close before the transaction is used again.
+//
+//	cp, err := tx.CopyIn("people", "name", "email")
+//	for _, p := range people {
+//		err = cp.Record(p)
+//	}
+//	n, err := cp.Close()
+func (tx *Tx) CopyIn(table string, columns ...string) (*CopyInStmt, error) {
+	if len(columns) == 0 {
+		panic("CopyIn requires columns")
+	}
+	if tx.DriverName() != "postgres" {
+		return nil, dat.ErrInvalidOperation
+	}
+
+	stmt, err := tx.Tx.Prepare(pq.CopyIn(table, columns...))
+	if err != nil {
+		return nil, logger.Error("copyIn.prepare", "err", err, "table", table)
+	}
+	return &CopyInStmt{stmt: stmt, columns: columns}, nil
+}
+
+// Values sends a row of values for the columns.
+func (c *CopyInStmt) Values(vals ...interface{}) error {
+	if len(vals) != len(c.columns) {
+		panic("Values requires a value for each column")
+	}
+	_, err := c.stmt.Exec(vals...)
+	return err
+}
+
+// Record sends the fields of rec, a struct or pointer to a struct, mapped to
+// the columns by their db tags.
+func (c *CopyInStmt) Record(rec interface{}) error {
+	vals, err := dat.RecordValues(rec, c.columns...)
+	if err != nil {
+		return err
+	}
+	return c.Values(vals...)
+}
+
+// Records sends each struct of records, a slice or array.
+func (c *CopyInStmt) Records(records interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(records))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		panic("Records requires a slice of structs")
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := c.Record(v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close completes the copy and returns the number of rows copied.
+func (c *CopyInStmt) Close() (int64, error) {
+	res, err := c.stmt.Exec()
+	closeErr := c.stmt.Close()
+	if err != nil {
+		return 0, err
+	}
+	if closeErr != nil {
+		return 0, closeErr
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"testing"
+
+	"gopkg.in/mgutz/dat.v1"
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+func TestCopyIn(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	cp, err := s.CopyIn("people", "name", "email")
+	assert.NoError(t, err)
+	assert.NoError(t, cp.Values("Copy 1", "copy1@acme.com"))
+	assert.NoError(t, cp.Record(&Person{Name: "Copy 2", Email: dat.NullStringFrom("copy2@acme.com")}))
+
+	people := []Person{}
+	for i := 0; i < 1000; i++ {
+		people = append(people, Person{Name: "Bulk"})
+	}
+	assert.NoError(t, cp.Records(people))
+	assert.NoError(t, cp.Records(&[2]Person{{Name: "Bulk"}, {Name: "Bulk"}}))
+
+	n, err := cp.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1004), n)
+
+	var count int64
+	err = s.Select("count(*)").From("people").Where("name LIKE $1", "Copy%").QueryScalar(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	var email string
+	err = s.Select("email").From("people").Where("name = $1", "Copy 2").QueryScalar(&email)
+	assert.NoError(t, err)
+	assert.Equal(t, "copy2@acme.com", email)
+
+	err = s.Select("count(*)").From("people").Where("name = $1", "Bulk").QueryScalar(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1002), count)
+}
+
+func TestCopyInInvalidColumn(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	// the COPY statement is sent on prepare
+	cp, err := s.CopyIn("people", "name", "missing")
+	assert.Error(t, err)
+	assert.Nil(t, cp)
+}
+
+func TestCopyInRecordMissingColumn(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	cp, err := s.CopyIn("people", "name", "email")
+	assert.NoError(t, err)
+
+	// no field is tagged email
+	rec := struct {
+		Name string `db:"name"`
+	}{"Copy 1"}
+	assert.Error(t, cp.Record(&rec))
+
+	n, err := cp.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+}
@@ -2,9 +2,13 @@ package runner
 
 import (
 	"database/sql"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 	"gopkg.in/mgutz/dat.v1"
+	"gopkg.in/mgutz/dat.v1/mysql"
+	"gopkg.in/mgutz/dat.v1/postgres"
+	"gopkg.in/mgutz/dat.v1/sqlite"
 )
 
 // DB represents an abstract database connection pool.
@@ -12,6 +16,9 @@ type DB struct {
 	DB *sqlx.DB
 	*Queryable
 	Version int64
+	// ConnectionString is used by Listen to open a dedicated connection. It
+	// is set by NewDBFromString, set it after NewDB to Listen.
+	ConnectionString string
 }
 
 var standardConformingStrings string
@@ -52,18 +59,64 @@ func pgSetVersion(db *DB) {
 	}
 }
 
+// mysqlMustAllowEscapeSequence checks if MySQL treats backslashes as escape
+// characters when dat.EnableInterpolation == true. The MySQL dialect escapes
+// strings with backslashes, so it is unsafe to interpolate with
+// NO_BACKSLASH_ESCAPES and this function panics.
+func mysqlMustAllowEscapeSequence(conn *DB) {
+	if !dat.EnableInterpolation {
+		return
+	}
+
+	var sqlMode string
+	err := conn.SQL("SELECT @@SESSION.sql_mode").QueryScalar(&sqlMode)
+	if err != nil {
+		panic(err)
+	}
+
+	if strings.Contains(sqlMode, "NO_BACKSLASH_ESCAPES") {
+		logger.Fatal("Database does not allow escape sequences. Cannot be used with interpolation. "+
+			"See https://dev.mysql.com/doc/refman/5.7/en/sql-mode.html#sqlmode_no_backslash_escapes",
+			"sql_mode", sqlMode)
+	}
+}
+
 // NewDB instantiates a Connection for a given database/sql connection
 func NewDB(db *sql.DB, driverName string) *DB {
-	database := sqlx.NewDb(db, driverName)
-	conn := &DB{DB: database, Queryable: &Queryable{database}}
-	if driverName == "postgres" {
+	return newDB(sqlx.NewDb(db, driverName))
+}
+
+// dialectFor returns the dialect for a driver, or nil if the driver is not
+// supported.
+func dialectFor(driverName string) dat.SQLDialect {
+	switch driverName {
+	case "postgres":
+		return postgres.New()
+	case "mysql":
+		return mysql.New()
+	case "sqlite3":
+		return sqlite.New()
+	}
+	return nil
+}
+
+func newDB(database *sqlx.DB) *DB {
+	driverName := database.DriverName()
+	dialect := dialectFor(driverName)
+	if dialect == nil {
+		panic("Unsupported driver: " + driverName)
+	}
+
+	conn := &DB{DB: database, Queryable: &Queryable{database, dialect}}
+	switch driverName {
+	case "postgres":
 		pgMustNotAllowEscapeSequence(conn)
 		pgSetVersion(conn)
 		if dat.Strict {
 			conn.SQL("SET client_min_messages to 'DEBUG';")
 		}
-	} else {
-		panic("Unsupported driver: " + driverName)
+	case "mysql":
+		mysqlMustAllowEscapeSequence(conn)
 	}
 	return conn
 }
@@ -79,13 +132,12 @@ func NewDBFromString(driver string, connectionString string) *DB {
 	if err != nil {
 		logger.Fatal("Could not ping database", "err", err)
 	}
-	return NewDB(db, driver)
+	conn := NewDB(db, driver)
+	conn.ConnectionString = connectionString
+	return conn
 }
 
 // NewDBFromSqlx creates a new Connection object from existing Sqlx.DB.
 func NewDBFromSqlx(dbx *sqlx.DB) *DB {
-	conn := &DB{DB: dbx, Queryable: &Queryable{dbx}}
-	pgMustNotAllowEscapeSequence(conn)
-	pgSetVersion(conn)
-	return conn
+	return newDB(dbx)
 }
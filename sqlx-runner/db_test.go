@@ -3,6 +3,9 @@ package runner
 import (
 	"testing"
 
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/mgutz/dat.v1"
+	"gopkg.in/mgutz/dat.v1/postgres"
 	"gopkg.in/stretchr/testify.v1/assert"
 )
 
@@ -10,3 +13,26 @@ func TestVersion(t *testing.T) {
 	// require at least 9.3+ for testing
 	assert.True(t, testDB.Version > 90300)
 }
+
+func TestDialectPerConnection(t *testing.T) {
+	assert.IsType(t, &postgres.Postgres{}, testDB.Dialect())
+
+	my := WrapSqlxExt(sqlx.NewDb(sqlDB, "mysql"))
+	sql, args := my.Update("b").Set("a", 1).Where("c = $1", 2).ToSQL()
+	assert.Equal(t, "UPDATE `b` SET `a` = ? WHERE (c = ?)", sql)
+	assert.Equal(t, []interface{}{1, 2}, args)
+
+	// the Postgres connection and package builders are unaffected
+	sql, _ = testDB.Update("b").Set("a", 1).Where("c = $1", 2).ToSQL()
+	assert.Equal(t, `UPDATE "b" SET "a" = $1 WHERE (c = $2)`, sql)
+	sql, _ = dat.Update("b").Set("a", 1).Where("c = $1", 2).ToSQL()
+	assert.Equal(t, `UPDATE "b" SET "a" = $1 WHERE (c = $2)`, sql)
+}
+
+func TestDialectTx(t *testing.T) {
+	tx, err := testDB.Begin()
+	assert.NoError(t, err)
+	defer tx.AutoRollback()
+
+	assert.Equal(t, testDB.Dialect(), tx.Dialect())
+}
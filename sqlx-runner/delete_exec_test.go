@@ -69,3 +69,44 @@ func TestDeleteScope(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualValues(t, count, 0)
 }
+
+func TestDeleteUsing(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	res, err := s.
+		DeleteFrom("comments").
+		Using("posts").
+		Where("posts.id = comments.post_id AND posts.title = $1", "Apple").
+		Exec()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, res.RowsAffected)
+
+	var count int64
+	err = s.Select("count(*)").From("comments").QueryScalar(&count)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestDeleteReturningLimit(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	var posts []*Post
+	err := s.
+		DeleteFrom("posts").
+		Where("id NOT IN (SELECT post_id FROM comments)").
+		OrderBy("id DESC").
+		Limit(1).
+		Returning("id", "title").
+		QueryStructs(&posts)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(posts))
+	assert.EqualValues(t, 4, posts[0].ID)
+	assert.Equal(t, "Orange", posts[0].Title)
+
+	var count int64
+	err = s.Select("count(*)").From("posts").QueryScalar(&count)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+}
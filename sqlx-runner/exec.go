@@ -2,18 +2,17 @@ package runner
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
-	guid "github.com/satori/go.uuid"
 	"gopkg.in/mgutz/dat.v1"
 	"gopkg.in/mgutz/dat.v1/kvs"
 )
@@ -21,11 +20,10 @@ import (
 // database is the interface for sqlx's DB or Tx against which
 // queries can be executed
 type database interface {
-	Exec(query string, args ...interface{}) (sql.Result, error)
-	Queryx(query string, args ...interface{}) (*sqlx.Rows, error)
-	QueryRowx(query string, args ...interface{}) *sqlx.Row
-	Select(dest interface{}, query string, args ...interface{}) error
-	Get(dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error)
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
 }
 
 func toOutputStr(args []interface{}) string {
@@ -51,15 +49,15 @@ func toOutputStr(args []interface{}) string {
 }
 
 func logSQLError(err error, msg string, statement string, args []interface{}) error {
-	// it might be possible for a query to finish in between ex.timeout expiring locally
-	// and before pg_cancel_backend executes on postgres server.
+	// A cancelled context aborts the query, which is not an error of the query
+	// itself. The caller coerces it into ErrTimedout where needed.
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return err
+	}
 	if pe, ok := err.(*pq.Error); ok {
 		if pe.Code == "57014" {
-			// dat initiates the cancellation of a query on timeout.  Coerce the error into
-			// a timedout error so the end user does not see a false error in the logs.
-			if strings.HasPrefix(statement, queryIDPrefix) {
-				return dat.ErrTimedout
-			}
+			// query_canceled, sent by the driver's cancel request
+			return err
 		}
 	} else if err == sql.ErrNoRows || err == dat.ErrNotFound {
 		if !LogErrNoRows {
@@ -99,29 +97,21 @@ func logExecutionTime(start time.Time, sql string, args []interface{}) {
 
 func (ex *Execer) exec() (sql.Result, error) {
 	if ex.timeout == 0 {
-		return ex.execFn()
+		return ex.execFn(ex.database, ex.ctx)
 	}
 
-	ch := make(chan bool, 1)
 	var result sql.Result
-	var err error
-	go func() {
-		result, err = ex.execFn()
-		ch <- true
-	}()
-	for {
-		select {
-		case <-time.After(ex.timeout):
-			return nil, ex.Cancel()
-		case <-ch:
-			return result, err
-		}
-	}
+	err := ex.withTimeout(func(q database, ctx context.Context) error {
+		var err error
+		result, err = ex.execFn(q, ctx)
+		return err
+	})
+	return result, err
 }
 
 // execFn executes the query built by builder. Use execFn when data is not
 // to be returned.
-func (ex *Execer) execFn() (sql.Result, error) {
+func (ex *Execer) execFn(q database, ctx context.Context) (sql.Result, error) {
 	fullSQL, args, err := ex.Interpolate()
 	if err != nil {
 		return nil, logger.Error("execFn.10", "err", err, "sql", fullSQL)
@@ -129,7 +119,7 @@ func (ex *Execer) execFn() (sql.Result, error) {
 	defer logExecutionTime(time.Now(), fullSQL, args)
 
 	var result sql.Result
-	result, err = ex.database.Exec(fullSQL, args...)
+	result, err = q.ExecContext(ctx, fullSQL, args...)
 	if err != nil {
 		return nil, logSQLError(err, "execFn.30:"+fmt.Sprintf("%T", err), fullSQL, args)
 	}
@@ -137,53 +127,48 @@ func (ex *Execer) execFn() (sql.Result, error) {
 	return result, nil
 }
 
-// execSQL executes SQL. DO NOT add timeout logic here since this is called
-// by Cancel when a timeout occurs.
-func (ex *Execer) execSQL(fullSQL string, args []interface{}) (sql.Result, error) {
-	defer logExecutionTime(time.Now(), fullSQL, args)
-
-	var result sql.Result
-	var err error
-	result, err = ex.database.Exec(fullSQL, args...)
-	if err != nil {
-		return nil, logSQLError(err, "execSQL.30", fullSQL, args)
-	}
-
-	return result, nil
-}
-
 func (ex *Execer) query() (*sqlx.Rows, error) {
 	if ex.timeout == 0 {
-		return ex.queryFn()
+		return ex.queryFn(ex.database, ex.ctx)
 	}
 
-	ch := make(chan bool, 1)
-	var rows *sqlx.Rows
-	var err error
-	go func() {
-		rows, err = ex.queryFn()
-		ch <- true
-	}()
-	for {
-		select {
-		case <-time.After(ex.timeout):
-			return nil, ex.Cancel()
-		case <-ch:
-			//logger.Error("doexec completed")
-			return rows, err
+	// The rows outlive this call, so the query cannot run on a connection
+	// checked out by withTimeout. The timeout bounds the query until its rows
+	// are returned, reading them is bounded by the context of WithContext. The
+	// timer is stopped once the query returns, the context it cancels is only
+	// cancelled through the parent, or Cancel while the query is in flight.
+	ctx, cancel := context.WithCancel(ex.ctx)
+	timer := time.AfterFunc(ex.timeout, cancel)
+	ex.setCancel(cancel)
+	rows, err := ex.queryFn(ex.database, ctx)
+	timedOut := !timer.Stop() && ex.ctx.Err() == nil
+	ex.setCancel(nil)
+
+	if err != nil {
+		cancel()
+		if timedOut {
+			return nil, dat.ErrTimedout
 		}
+		return nil, err
+	}
+	if timedOut {
+		// the timer fired as the rows arrived
+		rows.Close()
+		cancel()
+		return nil, dat.ErrTimedout
 	}
+	return rows, nil
 }
 
 // Query delegates to the internal runner's Query.
-func (ex *Execer) queryFn() (*sqlx.Rows, error) {
+func (ex *Execer) queryFn(q database, ctx context.Context) (*sqlx.Rows, error) {
 	fullSQL, args, err := ex.Interpolate()
 	if err != nil {
 		return nil, err
 	}
 
 	defer logExecutionTime(time.Now(), fullSQL, args)
-	rows, err := ex.database.Queryx(fullSQL, args...)
+	rows, err := q.QueryxContext(ctx, fullSQL, args...)
 	if err != nil {
 		return nil, logSQLError(err, "queryFn.30", fullSQL, args)
 	}
@@ -193,30 +178,19 @@ func (ex *Execer) queryFn() (*sqlx.Rows, error) {
 
 func (ex *Execer) queryScalar(destinations ...interface{}) error {
 	if ex.timeout == 0 {
-		return ex.queryScalarFn(destinations)
-	}
-
-	ch := make(chan bool, 1)
-	var err error
-	go func() {
-		err = ex.queryScalarFn(destinations)
-		ch <- true
-	}()
-	for {
-		select {
-		case <-time.After(ex.timeout):
-			return ex.Cancel()
-		case <-ch:
-			return err
-		}
+		return ex.queryScalarFn(ex.database, ex.ctx, destinations)
 	}
+
+	return ex.withTimeout(func(q database, ctx context.Context) error {
+		return ex.queryScalarFn(q, ctx, destinations)
+	})
 }
 
 // QueryScan executes the query in builder and loads the resulting data into
 // one or more destinations.
 //
 // Returns ErrNotFound if no value was found, and it was therefore not set.
-func (ex *Execer) queryScalarFn(destinations []interface{}) error {
+func (ex *Execer) queryScalarFn(q database, ctx context.Context, destinations []interface{}) error {
 	fullSQL, args, blob, err := ex.cacheOrSQL()
 	if err != nil {
 		return err
@@ -233,7 +207,7 @@ func (ex *Execer) queryScalarFn(destinations []interface{}) error {
 	defer logExecutionTime(time.Now(), fullSQL, args)
 	// Run the query:
 	var rows *sqlx.Rows
-	rows, err = ex.database.Queryx(fullSQL, args...)
+	rows, err = q.QueryxContext(ctx, fullSQL, args...)
 	if err != nil {
 		return logSQLError(err, "queryScalarFn.12: querying database", fullSQL, args)
 	}
@@ -256,30 +230,19 @@ func (ex *Execer) queryScalarFn(destinations []interface{}) error {
 
 func (ex *Execer) querySlice(dest interface{}) error {
 	if ex.timeout == 0 {
-		return ex.querySliceFn(dest)
-	}
-
-	ch := make(chan bool, 1)
-	var err error
-	go func() {
-		err = ex.querySliceFn(dest)
-		ch <- true
-	}()
-	for {
-		select {
-		case <-time.After(ex.timeout):
-			return ex.Cancel()
-		case <-ch:
-			return err
-		}
+		return ex.querySliceFn(ex.database, ex.ctx, dest)
 	}
+
+	return ex.withTimeout(func(q database, ctx context.Context) error {
+		return ex.querySliceFn(q, ctx, dest)
+	})
 }
 
 // QuerySlice executes the query in builder and loads the resulting data into a
 // slice of primitive values
 //
 // Returns ErrNotFound if no value was found, and it was therefore not set.
-func (ex *Execer) querySliceFn(dest interface{}) error {
+func (ex *Execer) querySliceFn(q database, ctx context.Context, dest interface{}) error {
 	// Validate the dest and reflection values we need
 
 	// This must be a pointer to a slice
@@ -319,7 +282,7 @@ func (ex *Execer) querySliceFn(dest interface{}) error {
 	}
 
 	defer logExecutionTime(time.Now(), fullSQL, args)
-	rows, err := ex.database.Queryx(fullSQL, args...)
+	rows, err := q.QueryxContext(ctx, fullSQL, args...)
 	if err != nil {
 		return logSQLError(err, "querySlice.load_all_values.query", fullSQL, args)
 	}
@@ -352,30 +315,19 @@ func (ex *Execer) querySliceFn(dest interface{}) error {
 
 func (ex *Execer) queryStruct(dest interface{}) error {
 	if ex.timeout == 0 {
-		return ex.queryStructFn(dest)
-	}
-
-	ch := make(chan bool, 1)
-	var err error
-	go func() {
-		err = ex.queryStructFn(dest)
-		ch <- true
-	}()
-	for {
-		select {
-		case <-time.After(ex.timeout):
-			return ex.Cancel()
-		case <-ch:
-			return err
-		}
+		return ex.queryStructFn(ex.database, ex.ctx, dest)
 	}
+
+	return ex.withTimeout(func(q database, ctx context.Context) error {
+		return ex.queryStructFn(q, ctx, dest)
+	})
 }
 
 // QueryStruct executes the query in builder and loads the resulting data into
 // a struct dest must be a pointer to a struct
 //
 // Returns ErrNotFound if nothing was found
-func (ex *Execer) queryStructFn(dest interface{}) error {
+func (ex *Execer) queryStructFn(q database, ctx context.Context, dest interface{}) error {
 	fullSQL, args, blob, err := ex.cacheOrSQL()
 	if err != nil {
 		return err
@@ -390,7 +342,7 @@ func (ex *Execer) queryStructFn(dest interface{}) error {
 	}
 
 	defer logExecutionTime(time.Now(), fullSQL, args)
-	err = ex.database.Get(dest, fullSQL, args...)
+	err = q.GetContext(ctx, dest, fullSQL, args...)
 	if err != nil {
 		return logSQLError(err, "queryStruct.3", fullSQL, args)
 	}
@@ -401,23 +353,12 @@ func (ex *Execer) queryStructFn(dest interface{}) error {
 
 func (ex *Execer) queryStructs(dest interface{}) error {
 	if ex.timeout == 0 {
-		return ex.queryStructsFn(dest)
-	}
-
-	ch := make(chan bool, 1)
-	var err error
-	go func() {
-		err = ex.queryStructsFn(dest)
-		ch <- true
-	}()
-	for {
-		select {
-		case <-time.After(ex.timeout):
-			return ex.Cancel()
-		case <-ch:
-			return err
-		}
+		return ex.queryStructsFn(ex.database, ex.ctx, dest)
 	}
+
+	return ex.withTimeout(func(q database, ctx context.Context) error {
+		return ex.queryStructsFn(q, ctx, dest)
+	})
 }
 
 // QueryStructs executes the query in builderand loads the resulting data into
@@ -425,7 +366,7 @@ func (ex *Execer) queryStructs(dest interface{}) error {
 //
 // Returns the number of items found (which is not necessarily the # of items
 // set)
-func (ex *Execer) queryStructsFn(dest interface{}) error {
+func (ex *Execer) queryStructsFn(q database, ctx context.Context, dest interface{}) error {
 	fullSQL, args, blob, err := ex.cacheOrSQL()
 	if err != nil {
 		logger.Error("queryStructs.1: Could not convert to SQL", "err", err)
@@ -441,7 +382,7 @@ func (ex *Execer) queryStructsFn(dest interface{}) error {
 	}
 
 	defer logExecutionTime(time.Now(), fullSQL, args)
-	err = ex.database.Select(dest, fullSQL, args...)
+	err = q.SelectContext(ctx, dest, fullSQL, args...)
 	if err != nil {
 		logSQLError(err, "queryStructs", fullSQL, args)
 	}
@@ -467,31 +408,23 @@ func (ex *Execer) queryJSONStruct(dest interface{}) error {
 
 func (ex *Execer) queryJSONBlob(single bool) ([]byte, error) {
 	if ex.timeout == 0 {
-		return ex.queryJSONBlobFn(single)
+		return ex.queryJSONBlobFn(ex.database, ex.ctx, single)
 	}
 
-	ch := make(chan bool, 1)
-	var err error
 	var b []byte
-	go func() {
-		b, err = ex.queryJSONBlobFn(single)
-		ch <- true
-	}()
-	for {
-		select {
-		case <-time.After(ex.timeout):
-			return nil, ex.Cancel()
-		case <-ch:
-			return b, err
-		}
-	}
+	err := ex.withTimeout(func(q database, ctx context.Context) error {
+		var err error
+		b, err = ex.queryJSONBlobFn(q, ctx, single)
+		return err
+	})
+	return b, err
 }
 
 // queryJSONBlob executes the query in builder and loads the resulting data
 // into a blob. If a single item is to be returned, set single to true.
 //
 // Returns ErrNotFound if nothing was found
-func (ex *Execer) queryJSONBlobFn(single bool) ([]byte, error) {
+func (ex *Execer) queryJSONBlobFn(q database, ctx context.Context, single bool) ([]byte, error) {
 	fullSQL, args, blob, err := ex.cacheOrSQL()
 	if err != nil {
 		return nil, err
@@ -501,7 +434,7 @@ func (ex *Execer) queryJSONBlobFn(single bool) ([]byte, error) {
 	}
 
 	defer logExecutionTime(time.Now(), fullSQL, args)
-	rows, err := ex.database.Queryx(fullSQL, args...)
+	rows, err := q.QueryxContext(ctx, fullSQL, args...)
 	if err != nil {
 		return nil, logSQLError(err, "queryJSONStructs", fullSQL, args)
 	}
@@ -598,6 +531,7 @@ func (ex *Execer) cacheOrSQL() (string, []interface{}, []byte, error) {
 	if Cache != nil && ex.cacheTTL > 0 && ex.cacheID == "" {
 		// this must be set for setCache() to work below
 		ex.cacheID = kvs.Hash(fullSQL)
+		ex.cacheIDHashed = true
 
 		if !ex.cacheInvalidate {
 			v, err := Cache.Get(ex.cacheID)
@@ -655,32 +589,23 @@ func (ex *Execer) setCache(data interface{}, dataType int) {
 
 func (ex *Execer) queryJSON() ([]byte, error) {
 	if ex.timeout == 0 {
-		return ex.queryJSONFn()
+		return ex.queryJSONFn(ex.database, ex.ctx)
 	}
 
-	ch := make(chan bool, 1)
-	var err error
 	var b []byte
-	go func() {
-		b, err = ex.queryJSONFn()
-		ch <- true
-	}()
-	for {
-		select {
-		case <-time.After(ex.timeout):
-			return nil, ex.Cancel()
-		case <-ch:
-			//logger.Error("doexec completed")
-			return b, err
-		}
-	}
+	err := ex.withTimeout(func(q database, ctx context.Context) error {
+		var err error
+		b, err = ex.queryJSONFn(q, ctx)
+		return err
+	})
+	return b, err
 }
 
 // queryJSON executes the query in builder and loads the resulting JSON into
 // a bytes slice compatible.
 //
 // Returns ErrNotFound if nothing was found
-func (ex *Execer) queryJSONFn() ([]byte, error) {
+func (ex *Execer) queryJSONFn(q database, ctx context.Context) ([]byte, error) {
 	fullSQL, args, blob, err := ex.cacheOrSQL()
 	if err != nil {
 		return nil, err
@@ -692,7 +617,7 @@ func (ex *Execer) queryJSONFn() ([]byte, error) {
 	defer logExecutionTime(time.Now(), fullSQL, args)
 	jsonSQL := fmt.Sprintf("SELECT TO_JSON(ARRAY_AGG(__datq.*)) FROM (%s) AS __datq", fullSQL)
 
-	err = ex.database.Get(&blob, jsonSQL, args...)
+	err = q.GetContext(ctx, &blob, jsonSQL, args...)
 	if err != nil {
 		logSQLError(err, "queryJSON", jsonSQL, args)
 	}
@@ -715,8 +640,3 @@ func (ex *Execer) queryObject(dest interface{}) error {
 	}
 	return nil
 }
-
-// uuid generates a UUID.
-func uuid() string {
-	return fmt.Sprintf("%s", guid.NewV4())
-}
@@ -1,8 +1,9 @@
 package runner
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -14,31 +15,56 @@ type Execer struct {
 	database
 	builder dat.Builder
 
+	// ctx is passed through to the driver, cancelling it aborts the query
+	ctx context.Context
+
 	cacheID         string
 	cacheTTL        time.Duration
 	cacheInvalidate bool
+	// cacheIDHashed is set if cacheID is the hash of the SQL, it is not
+	// copied to an Execer of another builder
+	cacheIDHashed bool
 
 	// timeout is the time to wait for a query before cancelling it, 0 means forever
 	timeout time.Duration
 
-	// uuid is prepended into the SQL for the query to be searched
-	// in pg_stat_activity, used by timeout logic
-	queryID string
+	// mu guards cancel, which is only set while a query with a timeout is in
+	// flight
+	mu     sync.Mutex
+	cancel context.CancelFunc
 }
 
-const queryIDPrefix = "--dat:qid="
-
 // NewExecer creates a new instance of Execer.
 func NewExecer(database database, builder dat.Builder) *Execer {
 	return &Execer{
 		database: database,
 		builder:  builder,
+		ctx:      context.Background(),
+	}
+}
+
+// BindBuilder returns a copy of the Execer which executes b. A cache id
+// passed to Cache is copied, so b shares the cached results.
+func (ex *Execer) BindBuilder(b dat.Builder) dat.Execer {
+	cacheID := ex.cacheID
+	if ex.cacheIDHashed {
+		cacheID = ""
+	}
+	return &Execer{
+		database:        ex.database,
+		builder:         b,
+		ctx:             ex.ctx,
+		cacheID:         cacheID,
+		cacheTTL:        ex.cacheTTL,
+		cacheInvalidate: ex.cacheInvalidate,
+		timeout:         ex.timeout,
 	}
 }
 
 // Cache caches the results of queries for Select and SelectDoc.
 func (ex *Execer) Cache(id string, ttl time.Duration, invalidate bool) dat.Execer {
 	ex.cacheID = id
+	ex.cacheIDHashed = false
 	ex.cacheTTL = ttl
 	ex.cacheInvalidate = invalidate
 	return ex
@@ -47,52 +73,83 @@ func (ex *Execer) Cache(id string, ttl time.Duration, invalidate bool) dat.Exece
 // Timeout sets the timeout for current query.
 func (ex *Execer) Timeout(timeout time.Duration) dat.Execer {
 	ex.timeout = timeout
-	if timeout > 0 {
-		ex.queryID = uuid()
-	} else {
-		ex.queryID = ""
+	return ex
+}
+
+// WithContext sets the context for the current query. The query is
+// aborted if ctx is cancelled or its deadline expires before the query
+// completes.
+func (ex *Execer) WithContext(ctx context.Context) dat.Execer {
+	if ctx == nil {
+		panic("nil context")
 	}
+	ex.ctx = ctx
 	return ex
 }
 
-func datQueryID(id string) string {
-	return fmt.Sprintf("--dat:qid=%s", id)
+// Cancel cancels the in-flight query started with a timeout. The driver sends
+// a cancel request to the backend running the statement. If no such query is
+// running then ErrInvalidOperation is returned.
+func (ex *Execer) Cancel() error {
+	ex.mu.Lock()
+	cancel := ex.cancel
+	ex.mu.Unlock()
+
+	if cancel == nil {
+		return dat.ErrInvalidOperation
+	}
+	cancel()
+	return dat.ErrTimedout
 }
 
-func prependDatQueryID(sql string, id string) string {
-	return fmt.Sprintf("%s\n%s", datQueryID(id), sql)
+func (ex *Execer) setCancel(cancel context.CancelFunc) {
+	ex.mu.Lock()
+	ex.cancel = cancel
+	ex.mu.Unlock()
 }
 
-// Cancel cancels last query with a queryID. If queryID was not set then
-// ErrInvalidOperation is returned.
-func (ex *Execer) Cancel() error {
-	if ex.queryID == "" {
-		return dat.ErrInvalidOperation
+// withTimeout runs fn on a connection checked out of the pool (or the
+// transaction's connection) with a deadline of ex.timeout. When the deadline
+// expires the driver cancels the exact backend running the statement, and the
+// connection is released once fn returns. The connection and context are
+// passed to fn, ex is not modified, so a builder may run concurrently.
+func (ex *Execer) withTimeout(fn func(q database, ctx context.Context) error) error {
+	parent := ex.ctx
+	ctx, cancel := context.WithTimeout(parent, ex.timeout)
+	defer cancel()
+
+	q := ex.database
+	if sdb, ok := q.(*sqlx.DB); ok {
+		c, err := sdb.Conn(ctx)
+		if err != nil {
+			return timeoutErr(ctx, parent, err)
+		}
+		defer c.Close()
+		q = &conn{Conn: c, mapper: sdb.Mapper, driverName: sdb.DriverName()}
 	}
 
-	q := fmt.Sprintf(`
-	SELECT pg_cancel_backend(psa.pid)
-	FROM (
-		SELECT pid
-		FROM pg_stat_activity
-		WHERE query
-		LIKE '%s%%'
-	) psa`, datQueryID(ex.queryID))
+	ex.setCancel(cancel)
+	defer ex.setCancel(nil)
 
-	_, err := ex.execSQL(q, nil)
-	if err != nil {
-		logger.Error("While trying to cancel a query", err)
+	err := timeoutErr(ctx, parent, fn(q, ctx))
+	if err == dat.ErrTimedout {
+		logger.Info("Query timed out", "timeout", ex.timeout)
 	}
-	return dat.ErrTimedout
+	return err
+}
+
+// timeoutErr coerces err into ErrTimedout if ctx's deadline, and not that of
+// its parent, aborted the query.
+func timeoutErr(ctx, parent context.Context, err error) error {
+	if err != nil && ctx.Err() == context.DeadlineExceeded && parent.Err() == nil {
+		return dat.ErrTimedout
+	}
+	return err
 }
 
 // Interpolate tells the associated builder to interpolate itself.
 func (ex *Execer) Interpolate() (string, []interface{}, error) {
-	sql, args, err := ex.builder.Interpolate()
-	if ex.timeout > 0 {
-		sql = prependDatQueryID(sql, ex.queryID)
-	}
-	return sql, args, err
+	return ex.builder.Interpolate()
 }
 
 // Exec executes a builder's query.
@@ -101,11 +158,7 @@ func (ex *Execer) Exec() (*dat.Result, error) {
 	if err != nil {
 		return nil, err
 	}
-	rowsAffected, err := res.RowsAffected()
-	if err != nil {
-		return nil, err
-	}
-	return &dat.Result{RowsAffected: rowsAffected}, nil
+	return newResult(res)
 }
 
 // Queryx executes builder's query and returns rows.
@@ -21,6 +21,7 @@ func init() {
 	dat.Dialect = postgres.New()
 	sqlDB = realDb()
 	testDB = NewDB(sqlDB, "postgres")
+	testDB.ConnectionString = os.Getenv("DAT_DSN")
 	dat.Strict = false
 	logxi.Suppress(true)
 
@@ -69,3 +69,27 @@ func TestInsectSelectWhere(t *testing.T) {
 	assert.Equal(t, "Mario", p.Name)
 	assert.Equal(t, "mario@acme.com", p.Email.String)
 }
+
+// Insect should return a row for each input row in order.
+func TestInsectMultiple(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	var people []Person
+	err := s.Insect("people").
+		Columns("name", "email").
+		Key("email").
+		Values("Luigi", "luigi@acme.com").
+		Values("Foo", "mario@acme.com").
+		Values("Luigi", "luigi@acme.com").
+		Values("Peach", "peach@acme.com").
+		Returning("id", "name", "email").
+		QueryStructs(&people)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(people))
+	assert.Equal(t, "Luigi", people[0].Name)
+	assert.EqualValues(t, 1, people[1].ID)
+	assert.Equal(t, "Mario", people[1].Name)
+	assert.Equal(t, people[0].ID, people[2].ID)
+	assert.Equal(t, "peach@acme.com", people[3].Email.String)
+}
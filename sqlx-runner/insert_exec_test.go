@@ -241,3 +241,55 @@ func TestInsertBytes(t *testing.T) {
 	assert.Exactly(t, b, image)
 	dat.EnableInterpolation = false
 }
+
+func TestInsertOnConflict(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	var people []Person
+	err := s.
+		InsertInto("people").
+		Columns("id", "name", "email").
+		Values(1, "Mario", "mario@fixed.com").
+		Values(100, "Luigi", "luigi@acme.com").
+		OnConflict("id").
+		DoUpdateSetExcluded("email").
+		DoUpdateWhere("people.email <> EXCLUDED.email").
+		Returning("id", "name", "email").
+		QueryStructs(&people)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(people))
+	assert.EqualValues(t, 1, people[0].ID)
+	assert.Equal(t, "mario@fixed.com", people[0].Email.String)
+	assert.EqualValues(t, 100, people[1].ID)
+
+	res, err := s.
+		InsertInto("people").
+		Columns("id", "name").
+		Values(1, "Mario").
+		Values(101, "Peach").
+		OnConflict("id").
+		DoNothing().
+		Exec()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, res.RowsAffected)
+}
+
+func TestInsertFromSelect(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	var people []Person
+	err := s.
+		InsertInto("people").
+		Columns("name", "email").
+		FromSelect(dat.Select("name || '2'", "email").From("people").Where("id < $1", 3)).
+		Returning("id", "name").
+		QueryStructs(&people)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(people))
+	for _, p := range people {
+		assert.True(t, p.ID > 6)
+		assert.True(t, strings.HasSuffix(p.Name, "2"))
+	}
+}
@@ -0,0 +1,179 @@
+package runner
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"gopkg.in/mgutz/dat.v1"
+)
+
+// ErrNoConnectionString occurs when listening on a DB whose connection
+// string is unknown.
+var ErrNoConnectionString = errors.New("DB.ConnectionString is not set, use NewDBFromString")
+
+// ErrListenTimedout occurs when a Subscription does not connect within
+// ListenConnectTimeout.
+var ErrListenTimedout = errors.New("timed out connecting to listen")
+
+// ListenMinReconnect and ListenMaxReconnect bound the interval between
+// attempts to reconnect a Subscription.
+var (
+	ListenMinReconnect = 10 * time.Second
+	ListenMaxReconnect = time.Minute
+)
+
+// ListenConnectTimeout bounds the first connection of a Subscription.
+var ListenConnectTimeout = 10 * time.Second
+
+// listenPingInterval is how often an idle Subscription checks its
+// connection.
+const listenPingInterval = 90 * time.Second
+
+// Notification is a notification received by a Subscription.
+type Notification struct {
+	Channel string
+	Payload string
+	// PID is the backend process which sent the notification.
+	PID int
+	// Reconnected is set, with no channel, after the subscription lost its
+	// connection. Notifications sent in the meantime are lost.
+	Reconnected bool
+}
+
+// Subscription delivers notifications of the channels it listens to. The
+// connection is re-established and the channels are listened to again
+// if it is lost.
+type Subscription struct {
+	listener      *pq.Listener
+	notifications chan *Notification
+	done          chan struct{}
+	closeOnce     sync.Once
+}
+
+// Listen creates a Subscription on a dedicated connection which listens to
+// channels. It fails if the connection cannot be made within
+// ListenConnectTimeout, and with ErrInvalidOperation unless the driver is
+// postgres. The subscription must be closed.
+//
+//	sub, err := DB.Listen("posts")
+//	defer sub.Close()
+//	for n := range sub.Notifications() {
+//		cache.Invalidate(n.Payload)
+//	}
+func (db *DB) Listen(channels ...string) (*Subscription, error) {
+	if db.DB.DriverName() != "postgres" {
+		return nil, dat.ErrInvalidOperation
+	}
+	if db.ConnectionString == "" {
+		return nil, ErrNoConnectionString
+	}
+
+	// the outcome of the first connection attempt, Listen blocks until the
+	// listener is connected
+	connected := make(chan error, 1)
+	listener := pq.NewListener(db.ConnectionString, ListenMinReconnect, ListenMaxReconnect,
+		func(ev pq.ListenerEventType, err error) {
+			if ev == pq.ListenerEventConnected || ev == pq.ListenerEventConnectionAttemptFailed {
+				select {
+				case connected <- err:
+				default:
+				}
+			}
+			if err != nil {
+				logger.Warn("Listener connection", "event", ev, "err", err)
+			}
+		})
+
+	timer := time.NewTimer(ListenConnectTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-connected:
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+	case <-timer.C:
+		listener.Close()
+		return nil, ErrListenTimedout
+	}
+
+	sub := &Subscription{
+		listener:      listener,
+		notifications: make(chan *Notification, 64),
+		done:          make(chan struct{}),
+	}
+	for _, channel := range channels {
+		if err := sub.Listen(channel); err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+
+	go sub.run()
+	return sub, nil
+}
+
+// Notifications returns the channel on which notifications are delivered.
+// It is closed when the subscription is closed.
+func (s *Subscription) Notifications() <-chan *Notification {
+	return s.notifications
+}
+
+// Listen starts listening to channel.
+func (s *Subscription) Listen(channel string) error {
+	return s.listener.Listen(channel)
+}
+
+// Unlisten stops listening to channel.
+func (s *Subscription) Unlisten(channel string) error {
+	return s.listener.Unlisten(channel)
+}
+
+// Close stops listening and closes the connection.
+func (s *Subscription) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		err = s.listener.Close()
+	})
+	return err
+}
+
+func (s *Subscription) run() {
+	defer close(s.notifications)
+
+	ping := time.NewTicker(listenPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ping.C:
+			go s.listener.Ping()
+		case n, ok := <-s.listener.Notify:
+			if !ok {
+				return
+			}
+			// a nil notification follows a reconnect
+			notification := &Notification{Reconnected: true}
+			if n != nil {
+				notification = &Notification{Channel: n.Channel, Payload: n.Extra, PID: n.BePid}
+			}
+			select {
+			case s.notifications <- notification:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+// Notify sends a notification with payload on channel. In a transaction it
+// is delivered when the transaction commits.
+func (q *Queryable) Notify(channel string, payload string) error {
+	_, err := q.Exec("SELECT pg_notify($1, $2)", channel, payload)
+	return err
+}
@@ -0,0 +1,36 @@
+package runner
+
+import "gopkg.in/mgutz/dat.v1"
+
+// Page is a page of rows and the total number of rows of a query.
+type Page struct {
+	// Items is the destination passed to QueryPage
+	Items   interface{}
+	Total   int64
+	Page    uint64
+	PerPage uint64
+}
+
+// QueryPage loads page, which starts at 1, of the rows of b into dest with
+// QueryStructs and counts all rows with b.CountBuilder. b is not modified.
+//
+//	var posts []*Post
+//	page, err := runner.QueryPage(DB.Select("*").From("posts").OrderBy("id"), 2, 20, &posts)
+func QueryPage(b *dat.SelectBuilder, page, perPage uint64, dest interface{}) (*Page, error) {
+	if page < 1 || perPage < 1 {
+		panic("QueryPage requires page and perPage >= 1")
+	}
+
+	err := b.Clone().Paginate(page, perPage).QueryStructs(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	err = b.CountBuilder().QueryScalar(&total)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Page{Items: dest, Total: total, Page: page, PerPage: perPage}, nil
+}
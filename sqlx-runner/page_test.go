@@ -0,0 +1,30 @@
+package runner
+
+import (
+	"testing"
+
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+func TestQueryPage(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	b := s.Select("id", "title").From("posts").Where("id > $1", 0).OrderBy("id")
+
+	var posts []*Post
+	page, err := QueryPage(b, 2, 3, &posts)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), page.Total)
+	assert.Equal(t, uint64(2), page.Page)
+	assert.Equal(t, uint64(3), page.PerPage)
+	assert.Equal(t, 1, len(posts))
+	assert.Equal(t, 4, posts[0].ID)
+	assert.Equal(t, &posts, page.Items)
+
+	var users []*Post
+	page, err = QueryPage(s.Select("user_id").Distinct().From("posts").OrderBy("user_id"), 1, 10, &users)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), page.Total)
+	assert.Equal(t, 2, len(users))
+}
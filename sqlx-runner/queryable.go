@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -11,6 +12,9 @@ import (
 // Queryable is an object that can be queried.
 type Queryable struct {
 	runner database
+	// dialect renders builders created from this Queryable, dat.Dialect is
+	// used if nil
+	dialect dat.SQLDialect
 }
 
 // WrapSqlxExt converts a sqlx.Ext to a *Queryable
@@ -19,13 +23,23 @@ func WrapSqlxExt(e sqlx.Ext) *Queryable {
 	default:
 		panic(fmt.Sprintf("unexpected type %T", e))
 	case database:
-		return &Queryable{e}
+		return &Queryable{e, dialectFor(driverName(e))}
 	}
 }
 
+// Dialect returns the dialect builders created from this Queryable render
+// with.
+func (q *Queryable) Dialect() dat.SQLDialect {
+	if q.dialect != nil {
+		return q.dialect
+	}
+	return dat.Dialect
+}
+
 // Call creates a new CallBuilder for the given sproc and args.
 func (q *Queryable) Call(sproc string, args ...interface{}) *dat.CallBuilder {
 	b := dat.NewCallBuilder(sproc, args...)
+	b.SetDialect(q.dialect)
 	b.Execer = NewExecer(q.runner, b)
 	return b
 }
@@ -33,41 +47,69 @@ func (q *Queryable) Call(sproc string, args ...interface{}) *dat.CallBuilder {
 // DeleteFrom creates a new DeleteBuilder for the given table.
 func (q *Queryable) DeleteFrom(table string) *dat.DeleteBuilder {
 	b := dat.NewDeleteBuilder(table)
+	b.SetDialect(q.dialect)
 	b.Execer = NewExecer(q.runner, b)
 	return b
 }
 
 // Exec executes a SQL query with optional arguments.
 func (q *Queryable) Exec(cmd string, args ...interface{}) (*dat.Result, error) {
+	return q.ExecContext(context.Background(), cmd, args...)
+}
+
+// ExecContext executes a SQL query with optional arguments. The query is
+// aborted if ctx is cancelled.
+func (q *Queryable) ExecContext(ctx context.Context, cmd string, args ...interface{}) (*dat.Result, error) {
 	var result sql.Result
 	var err error
 
+	cmd, args = dat.NewRawBuilder(cmd, args...).SetDialect(q.dialect).ToSQL()
 	if len(args) == 0 {
-		result, err = q.runner.Exec(cmd)
+		result, err = q.runner.ExecContext(ctx, cmd)
 	} else {
-		result, err = q.runner.Exec(cmd, args...)
+		result, err = q.runner.ExecContext(ctx, cmd, args...)
 	}
 	if err != nil {
 		return nil, logSQLError(err, "Exec", cmd, args)
 	}
-	rowsAffected, err := result.RowsAffected()
+	res, err := newResult(result)
 	if err != nil {
 		return nil, logSQLError(err, "Exec", cmd, args)
 	}
-	return &dat.Result{RowsAffected: rowsAffected}, nil
+	return res, nil
+}
+
+// newResult converts a sql.Result. LastInsertID is only set for drivers
+// which support it, such as MySQL.
+func newResult(result sql.Result) (*dat.Result, error) {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	res := &dat.Result{RowsAffected: rowsAffected}
+	if id, err := result.LastInsertId(); err == nil {
+		res.LastInsertID = id
+	}
+	return res, nil
 }
 
 // ExecBuilder executes the SQL in builder.
 func (q *Queryable) ExecBuilder(b dat.Builder) error {
+	return q.ExecBuilderContext(context.Background(), b)
+}
+
+// ExecBuilderContext executes the SQL in builder. The query is aborted if
+// ctx is cancelled.
+func (q *Queryable) ExecBuilderContext(ctx context.Context, b dat.Builder) error {
 	sql, args, err := b.Interpolate()
 	if err != nil {
 		return err
 	}
 
 	if len(args) == 0 {
-		_, err = q.runner.Exec(sql)
+		_, err = q.runner.ExecContext(ctx, sql)
 	} else {
-		_, err = q.runner.Exec(sql, args...)
+		_, err = q.runner.ExecContext(ctx, sql, args...)
 	}
 	if err != nil {
 		return logSQLError(err, "ExecBuilder", sql, args)
@@ -78,8 +120,16 @@ func (q *Queryable) ExecBuilder(b dat.Builder) error {
 // ExecMulti executes multiple SQL statements returning the number of
 // statements executed, or the index at which an error occurred.
 func (q *Queryable) ExecMulti(commands ...*dat.Expression) (int, error) {
+	return q.ExecMultiContext(context.Background(), commands...)
+}
+
+// ExecMultiContext executes multiple SQL statements returning the number of
+// statements executed, or the index at which an error occurred. Statements
+// not yet executed are skipped if ctx is cancelled.
+func (q *Queryable) ExecMultiContext(ctx context.Context, commands ...*dat.Expression) (int, error) {
 	for i, cmd := range commands {
-		_, err := q.runner.Exec(cmd.Sql, cmd.Args...)
+		sql, args := dat.NewRawBuilder(cmd.Sql, cmd.Args...).SetDialect(q.dialect).ToSQL()
+		_, err := q.runner.ExecContext(ctx, sql, args...)
 		if err != nil {
 			return i, err
 		}
@@ -90,6 +140,7 @@ func (q *Queryable) ExecMulti(commands ...*dat.Expression) (int, error) {
 // InsertInto creates a new InsertBuilder for the given table.
 func (q *Queryable) InsertInto(table string) *dat.InsertBuilder {
 	b := dat.NewInsertBuilder(table)
+	b.SetDialect(q.dialect)
 	b.Execer = NewExecer(q.runner, b)
 	return b
 }
@@ -97,6 +148,7 @@ func (q *Queryable) InsertInto(table string) *dat.InsertBuilder {
 // Insect inserts or selects.
 func (q *Queryable) Insect(table string) *dat.InsectBuilder {
 	b := dat.NewInsectBuilder(table)
+	b.SetDialect(q.dialect)
 	b.Execer = NewExecer(q.runner, b)
 	return b
 }
@@ -104,6 +156,7 @@ func (q *Queryable) Insect(table string) *dat.InsectBuilder {
 // Select creates a new SelectBuilder for the given columns.
 func (q *Queryable) Select(columns ...string) *dat.SelectBuilder {
 	b := dat.NewSelectBuilder(columns...)
+	b.SetDialect(q.dialect)
 	b.Execer = NewExecer(q.runner, b)
 	return b
 }
@@ -111,6 +164,7 @@ func (q *Queryable) Select(columns ...string) *dat.SelectBuilder {
 // SelectDoc creates a new SelectBuilder for the given columns.
 func (q *Queryable) SelectDoc(columns ...string) *dat.SelectDocBuilder {
 	b := dat.NewSelectDocBuilder(columns...)
+	b.SetDialect(q.dialect)
 	b.Execer = NewExecer(q.runner, b)
 	return b
 }
@@ -118,6 +172,39 @@ func (q *Queryable) SelectDoc(columns ...string) *dat.SelectDocBuilder {
 // SQL creates a new raw SQL builder.
 func (q *Queryable) SQL(sql string, args ...interface{}) *dat.RawBuilder {
 	b := dat.NewRawBuilder(sql, args...)
+	b.SetDialect(q.dialect)
+	b.Execer = NewExecer(q.runner, b)
+	return b
+}
+
+// Union creates a new CompoundBuilder combining the distinct rows of queries.
+func (q *Queryable) Union(builders ...dat.Builder) *dat.CompoundBuilder {
+	return q.compound("UNION", builders)
+}
+
+// UnionAll creates a new CompoundBuilder combining all rows of queries.
+func (q *Queryable) UnionAll(builders ...dat.Builder) *dat.CompoundBuilder {
+	return q.compound("UNION ALL", builders)
+}
+
+// Intersect creates a new CompoundBuilder returning the rows common to all
+// queries.
+func (q *Queryable) Intersect(builders ...dat.Builder) *dat.CompoundBuilder {
+	return q.compound("INTERSECT", builders)
+}
+
+// Except creates a new CompoundBuilder returning the rows of the first query
+// which are not in the others.
+func (q *Queryable) Except(builders ...dat.Builder) *dat.CompoundBuilder {
+	return q.compound("EXCEPT", builders)
+}
+
+func (q *Queryable) compound(operator string, builders []dat.Builder) *dat.CompoundBuilder {
+	b := dat.NewCompoundBuilder(operator, builders...)
+	if b == nil {
+		panic(operator + " requires 2 or more queries")
+	}
+	b.SetDialect(q.dialect)
 	b.Execer = NewExecer(q.runner, b)
 	return b
 }
@@ -125,6 +212,7 @@ func (q *Queryable) SQL(sql string, args ...interface{}) *dat.RawBuilder {
 // Update creates a new UpdateBuilder for the given table.
 func (q *Queryable) Update(table string) *dat.UpdateBuilder {
 	b := dat.NewUpdateBuilder(table)
+	b.SetDialect(q.dialect)
 	b.Execer = NewExecer(q.runner, b)
 	return b
 }
@@ -132,6 +220,7 @@ func (q *Queryable) Update(table string) *dat.UpdateBuilder {
 // Upsert creates a new UpdateBuilder for the given table.
 func (q *Queryable) Upsert(table string) *dat.UpsertBuilder {
 	b := dat.NewUpsertBuilder(table)
+	b.SetDialect(q.dialect)
 	b.Execer = NewExecer(q.runner, b)
 	return b
 }
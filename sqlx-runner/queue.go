@@ -0,0 +1,184 @@
+package runner
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"gopkg.in/mgutz/dat.v1"
+)
+
+// Job statuses.
+const (
+	JobPending = "pending"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobDead    = "dead"
+)
+
+// ErrJobExpired occurs when a job is completed or failed after its
+// visibility timeout expired. It may have been dequeued again.
+var ErrJobExpired = errors.New("job visibility timeout expired")
+
+// Queue is a work queue stored in a Postgres table. Workers dequeue jobs
+// with FOR UPDATE SKIP LOCKED, concurrent workers never receive the same
+// job.
+//
+// A dequeued job is running and hidden for VisibilityTimeout. A job which is
+// neither completed nor failed in time is dequeued again. A failed job is
+// retried after RetryDelay. After MaxAttempts a job is dead lettered, its
+// status is JobDead.
+type Queue struct {
+	Table             string
+	VisibilityTimeout time.Duration
+	RetryDelay        time.Duration
+	MaxAttempts       int
+}
+
+// Job is a row of a Queue.
+type Job struct {
+	ID        int64          `db:"id"`
+	Payload   dat.JSON       `db:"payload"`
+	Status    string         `db:"status"`
+	Attempts  int            `db:"attempts"`
+	LastError dat.NullString `db:"last_error"`
+	VisibleAt dat.NullTime   `db:"visible_at"`
+	CreatedAt dat.NullTime   `db:"created_at"`
+}
+
+var jobColumns = []string{"id", "payload", "status", "attempts", "last_error", "visible_at", "created_at"}
+
+// NewQueue creates a Queue for table with a visibility timeout of 5 minutes,
+// a retry delay of 10 seconds and 5 attempts.
+func NewQueue(table string) *Queue {
+	if table == "" {
+		logger.Error("Queue requires a table")
+		return nil
+	}
+	return &Queue{
+		Table:             table,
+		VisibilityTimeout: 5 * time.Minute,
+		RetryDelay:        10 * time.Second,
+		MaxAttempts:       5,
+	}
+}
+
+// CreateTable creates the table of the queue if it does not exist.
+func (q *Queue) CreateTable(conn Connection) error {
+	_, err := conn.Exec(`
+CREATE TABLE IF NOT EXISTS ` + q.Table + ` (
+	id bigserial PRIMARY KEY,
+	payload jsonb,
+	status text NOT NULL DEFAULT 'pending',
+	attempts int NOT NULL DEFAULT 0,
+	last_error text,
+	visible_at timestamptz NOT NULL DEFAULT now(),
+	created_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS ` + q.Table + `_visible_at_idx ON ` + q.Table + ` (visible_at, id)
+	WHERE status IN ('pending', 'running')`)
+	return err
+}
+
+// Enqueue adds a job whose payload is marshalled to JSON and returns its id.
+func (q *Queue) Enqueue(conn Connection, payload interface{}) (int64, error) {
+	j, err := dat.NewJSON(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = conn.
+		InsertInto(q.Table).
+		Columns("payload").
+		Values(j).
+		Returning("id").
+		QueryScalar(&id)
+	return id, err
+}
+
+// Dequeue marks up to n visible jobs running and returns them by id. No jobs
+// are returned unless n > 0. Pass a Tx to dequeue in a transaction.
+func (q *Queue) Dequeue(conn Connection, n int) ([]*Job, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	// dead letter jobs which timed out on their last attempt
+	_, err := conn.
+		Update(q.Table).
+		With("expired", dat.Select("id AS job_id").
+			From(q.Table).
+			Where("status = $1 AND visible_at <= now() AND attempts >= $2", JobRunning, q.MaxAttempts).
+			For("UPDATE", "SKIP LOCKED")).
+		Set("status", JobDead).
+		Set("last_error", "visibility timeout expired").
+		From("expired").
+		Where(q.Table + ".id = expired.job_id").
+		Exec()
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*Job
+	err = conn.
+		Update(q.Table).
+		With("next", dat.Select("id AS job_id").
+			From(q.Table).
+			Where("status IN ($1, $2) AND visible_at <= now() AND attempts < $3", JobPending, JobRunning, q.MaxAttempts).
+			OrderBy("visible_at, id").
+			Limit(uint64(n)).
+			For("UPDATE", "SKIP LOCKED")).
+		Set("status", JobRunning).
+		Set("attempts", dat.Expr("attempts + 1")).
+		Set("visible_at", q.after(q.VisibilityTimeout)).
+		From("next").
+		Where(q.Table + ".id = next.job_id").
+		Returning(jobColumns...).
+		QueryStructs(&jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID < jobs[j].ID
+	})
+	return jobs, nil
+}
+
+// Complete marks a dequeued job done.
+func (q *Queue) Complete(conn Connection, job *Job) error {
+	return q.finish(job, conn.Update(q.Table).Set("status", JobDone))
+}
+
+// Fail records cause and retries job after RetryDelay, or dead letters it
+// after MaxAttempts.
+func (q *Queue) Fail(conn Connection, job *Job, cause error) error {
+	b := conn.Update(q.Table).Set("last_error", cause.Error())
+	if job.Attempts >= q.MaxAttempts {
+		b.Set("status", JobDead)
+	} else {
+		b.Set("status", JobPending).Set("visible_at", q.after(q.RetryDelay))
+	}
+	return q.finish(job, b)
+}
+
+// finish updates job unless it was dequeued again.
+func (q *Queue) finish(job *Job, b *dat.UpdateBuilder) error {
+	res, err := b.
+		Where("id = $1 AND status = $2 AND attempts = $3", job.ID, JobRunning, job.Attempts).
+		Exec()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected == 0 {
+		return ErrJobExpired
+	}
+	return nil
+}
+
+// after returns the expression for now() + d.
+func (q *Queue) after(d time.Duration) *dat.Expression {
+	return dat.Expr("now() + $1::interval", strconv.FormatInt(d.Nanoseconds()/1000, 10)+" microseconds")
+}
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+func TestQueue(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	q := NewQueue("jobs")
+	assert.NoError(t, q.CreateTable(s))
+
+	for i := 1; i <= 3; i++ {
+		_, err := q.Enqueue(s, map[string]int{"n": i})
+		assert.NoError(t, err)
+	}
+
+	for _, n := range []int{0, -1} {
+		none, err := q.Dequeue(s, n)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(none))
+	}
+
+	jobs, err := q.Dequeue(s, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(jobs))
+	assert.Equal(t, JobRunning, jobs[0].Status)
+	assert.Equal(t, 1, jobs[0].Attempts)
+	assert.Equal(t, `{"n": 1}`, string(jobs[0].Payload))
+
+	assert.NoError(t, q.Complete(s, jobs[0]))
+	assert.Equal(t, ErrJobExpired, q.Complete(s, jobs[0]))
+	assert.NoError(t, q.Fail(s, jobs[1], errors.New("boom")))
+
+	// the running jobs are hidden, the failed job waits for RetryDelay
+	rest, err := q.Dequeue(s, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(rest))
+	assert.Equal(t, `{"n": 3}`, string(rest[0].Payload))
+
+	var status, lastError string
+	err = s.SQL("SELECT status, last_error FROM jobs WHERE id = $1", jobs[1].ID).QueryScalar(&status, &lastError)
+	assert.NoError(t, err)
+	assert.Equal(t, JobPending, status)
+	assert.Equal(t, "boom", lastError)
+}
+
+func TestQueueDeadLetter(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	q := NewQueue("jobs")
+	q.VisibilityTimeout = 0
+	q.RetryDelay = 0
+	q.MaxAttempts = 2
+	assert.NoError(t, q.CreateTable(s))
+
+	id, err := q.Enqueue(s, "a")
+	assert.NoError(t, err)
+	_, err = q.Enqueue(s, "b")
+	assert.NoError(t, err)
+
+	// now() is fixed in a transaction, timed out jobs are visible again
+	jobs, err := q.Dequeue(s, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, id, jobs[0].ID)
+	assert.NoError(t, q.Fail(s, jobs[0], errors.New("first")))
+
+	jobs, err = q.Dequeue(s, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, id, jobs[0].ID)
+	assert.Equal(t, 2, jobs[0].Attempts)
+	assert.NoError(t, q.Fail(s, jobs[0], errors.New("second")))
+
+	// b times out on its last attempt
+	jobs, err = q.Dequeue(s, 1)
+	assert.NoError(t, err)
+	jobs, err = q.Dequeue(s, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, jobs[0].Attempts)
+	jobs, err = q.Dequeue(s, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(jobs))
+
+	var dead []string
+	err = s.SQL("SELECT last_error FROM jobs WHERE status = $1 ORDER BY id", JobDead).QuerySlice(&dead)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"second", "visibility timeout expired"}, dead)
+}
@@ -320,3 +320,199 @@ func TestSelectFor(t *testing.T) {
 }
 
 // Series of tests that test mapping struct fields to columns
+
+func TestSelectWith(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	var titles []string
+	err := s.
+		Select("p.title").
+		With("authors", dat.Select("id").From("people").Where("name = $1", "Mario")).
+		From("posts p INNER JOIN authors a ON a.id = p.user_id").
+		Where("p.state = $1", "published").
+		QuerySlice(&titles)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Day 1"}, titles)
+
+	var ids []int64
+	err = s.
+		Select("n").
+		WithRecursive("seq(n)", "SELECT 1 UNION ALL SELECT n + 1 FROM seq WHERE n < $1", 3).
+		From("seq").
+		QuerySlice(&ids)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+}
+
+func TestUnionAll(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	var posts []*Post
+	err := s.
+		UnionAll(
+			dat.Select("id", "title").From("posts").Where("user_id = $1", 1),
+			dat.Select("id", "title").From("posts").Where("state = $1", "draft"),
+		).
+		OrderBy("id").
+		Limit(3).
+		QueryStructs(&posts)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(posts))
+	assert.Equal(t, []int{1, 2, 2}, []int{posts[0].ID, posts[1].ID, posts[2].ID})
+
+	var ids []int64
+	err = s.Except(
+		dat.Select("id").From("people"),
+		dat.Select("user_id").From("posts"),
+	).OrderBy("id").QuerySlice(&ids)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 4, 5, 6}, ids)
+}
+
+func TestSelectSubQueries(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	var names []string
+	err := s.
+		Select("name").
+		From("people").
+		Where("id IN $1 AND name != $2", dat.Select("user_id").From("posts").Where("state = $1", "published"), "").
+		OrderBy("name").
+		QuerySlice(&names)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"John", "Mario"}, names)
+
+	var count int64
+	err = s.
+		Select("count(*)").
+		FromSub(dat.Select("id").From("posts").Where("user_id = $1", 1), "t").
+		QueryScalar(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestSelectJoins(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	var titles []string
+	err := s.
+		Select("p.title").
+		From("people u").
+		Join("posts", "p", "p.user_id = u.id AND p.state = $1", "published").
+		Where("u.name = $1", "Mario").
+		QuerySlice(&titles)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Day 1"}, titles)
+
+	var names []string
+	err = s.
+		Select("u.name").
+		From("people u").
+		JoinLateral(dat.Select("id").From("posts").Where("user_id = u.id AND state = $1", "draft").Limit(1), "p", "").
+		OrderBy("u.id").
+		QuerySlice(&names)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Mario", "John"}, names)
+}
+
+func TestSelectColumnExpr(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	var rows []struct {
+		ID    int64  `db:"id"`
+		Label string `db:"label"`
+		N     int64  `db:"n"`
+	}
+	err := s.
+		Select("id").
+		ColumnExpr("CASE WHEN state = $1 THEN title ELSE $2 END AS label", "published", "-").
+		ColumnExpr("row_number() "+dat.Over("user_id", "id", "")+" AS n").
+		From("posts").
+		Where("user_id = $1", 1).
+		OrderBy("id").
+		QueryStructs(&rows)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(rows))
+	assert.Equal(t, "Day 1", rows[0].Label)
+	assert.Equal(t, "-", rows[1].Label)
+	assert.Equal(t, int64(2), rows[1].N)
+}
+
+func TestSelectPredicates(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	var ids []int64
+	err := s.
+		Select("id").
+		From("posts").
+		Where(dat.Or(
+			dat.And(dat.Eq{"user_id": 1}, dat.Like("title", "Day%")),
+			dat.In("title", []string{"Apple", "Pear"}),
+		)).
+		Where(dat.Not(dat.IsNull("state"))).
+		OrderBy("id").
+		QuerySlice(&ids)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+
+	var between []int64
+	err = s.
+		Select("id").
+		From("posts").
+		Where(dat.Between("id", 2, 3)).
+		OrderBy("id").
+		QuerySlice(&between)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2, 3}, between)
+}
+
+func TestSelectClone(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	base := s.Select("id").From("posts").Where("user_id = $1", 1).OrderBy("id")
+
+	var published []int64
+	err := base.Clone().Where("state = $1", "published").QuerySlice(&published)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, published)
+
+	var all []int64
+	err = base.QuerySlice(&all)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, all)
+}
+
+func TestSelectSeekAfter(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	order := []string{"user_id DESC", "id"}
+	var ids []int
+	cursor := ""
+	for page := 0; page < 3; page++ {
+		values, err := dat.DecodeCursor(cursor, len(order))
+		assert.NoError(t, err)
+
+		var posts []*Post
+		b := s.Select("id", "user_id", "title").From("posts").SeekAfter(order, values...).Limit(3)
+		err = b.QueryStructs(&posts)
+		assert.NoError(t, err)
+		for _, p := range posts {
+			ids = append(ids, p.ID)
+		}
+
+		cursor, err = b.NextCursor(&posts)
+		assert.NoError(t, err)
+		if cursor == "" {
+			break
+		}
+	}
+	assert.Equal(t, []int{3, 4, 1, 2}, ids)
+}
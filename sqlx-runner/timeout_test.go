@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -101,3 +102,69 @@ func TestTimeoutJSON(t *testing.T) {
 	assert.Equal(t, "john", obj.AsString("[0].name"))
 	assert.Equal(t, 10, obj.AsInt("[0].age"))
 }
+
+func TestTimeoutTx(t *testing.T) {
+	tx, err := testDB.Begin()
+	assert.NoError(t, err)
+	defer tx.AutoRollback()
+
+	_, err = tx.SQL("SELECT pg_sleep(1)").Timeout(10 * time.Millisecond).Exec()
+	assert.Equal(t, dat.ErrTimedout, err)
+}
+
+func TestTimeoutCancelsBackend(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		_, err := testDB.SQL("SELECT pg_sleep(2)").Timeout(10 * time.Millisecond).Exec()
+		assert.Equal(t, dat.ErrTimedout, err)
+	}
+
+	// cancelled statements must not linger on the server
+	var n int
+	err := testDB.SQL(`
+		SELECT count(*)
+		FROM pg_stat_activity
+		WHERE query = 'SELECT pg_sleep(2)' AND state = 'active'
+	`).QueryScalar(&n)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestCancelNotRunning(t *testing.T) {
+	ex := NewExecer(testDB.DB, dat.SQL("SELECT 1"))
+	ex.Timeout(time.Second)
+	assert.Equal(t, dat.ErrInvalidOperation, ex.Cancel())
+}
+
+func TestTimeoutConcurrent(t *testing.T) {
+	ex := NewExecer(testDB.DB, dat.SQL("SELECT pg_sleep(0.05)"))
+	ex.Timeout(time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ex.Exec()
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, dat.ErrInvalidOperation, ex.Cancel())
+}
+
+func TestTimeoutQueryxReadsRows(t *testing.T) {
+	ex := NewExecer(testDB.DB, dat.SQL("SELECT generate_series(1, 3)"))
+	ex.Timeout(50 * time.Millisecond)
+	rows, err := ex.Queryx()
+	assert.NoError(t, err)
+	defer rows.Close()
+
+	// the timeout does not tear down rows still being read
+	time.Sleep(100 * time.Millisecond)
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	assert.NoError(t, rows.Err())
+	assert.Equal(t, 3, n)
+}
@@ -32,7 +32,7 @@ type Tx struct {
 
 // WrapSqlxTx creates a Tx from a sqlx.Tx
 func WrapSqlxTx(tx *sqlx.Tx) *Tx {
-	newtx := &Tx{Tx: tx, Queryable: &Queryable{tx}}
+	newtx := &Tx{Tx: tx, Queryable: &Queryable{tx, dialectFor(tx.DriverName())}}
 	if dat.Strict {
 		time.AfterFunc(1*time.Minute, func() {
 			if !newtx.IsRollbacked && newtx.state == txPending {
@@ -53,7 +53,9 @@ func (db *DB) Begin() (*Tx, error) {
 		return nil, logger.Error("begin.error", err)
 	}
 	logger.Debug("begin tx")
-	return WrapSqlxTx(tx), nil
+	newtx := WrapSqlxTx(tx)
+	newtx.dialect = db.dialect
+	return newtx, nil
 }
 
 // Begin returns this transaction
@@ -232,3 +232,22 @@ func TestUpdateScopeFunc(t *testing.T) {
 	assert.Equal(t, person.Email.Valid, true)
 	assert.Equal(t, person.Email.String, "barack@whitehouse.gov")
 }
+
+func TestUpdateFrom(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	res, err := s.
+		Update("posts").
+		Set("title", dat.Expr("people.name || ': ' || posts.title")).
+		From("people").
+		Where("people.id = posts.user_id AND people.name = $1", "Mario").
+		Exec()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, res.RowsAffected)
+
+	var title string
+	err = s.Select("title").From("posts").Where("id = $1", 2).QueryScalar(&title)
+	assert.NoError(t, err)
+	assert.Equal(t, "Mario: Day 2", title)
+}
@@ -48,3 +48,47 @@ func TestUpsertReal(t *testing.T) {
 	assert.True(t, person.ID > 0)
 	assert.NotEqual(t, person.CreatedAt, dat.NullTime{})
 }
+
+func TestUpsertMultiple(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	people := []Person{
+		{ID: 1, Name: "Mario"},
+		{ID: 100, Name: "Luigi"},
+	}
+	people[0].Email = dat.NullStringFrom("mario@fixed.com")
+	people[1].Email = dat.NullStringFrom("luigi@acme.com")
+
+	var upserted []Person
+	err := s.Upsert("people").
+		Columns("id", "name", "email").
+		Key("id").
+		Records(people).
+		Values(2, "John", "john@fixed.com").
+		Returning("id", "name", "email").
+		QueryStructs(&upserted)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(upserted))
+
+	var count int
+	err = s.SQL("SELECT count(*) FROM people WHERE email LIKE '%@fixed.com' OR id = 100").QueryScalar(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestUpsertMultipleDuplicateKeys(t *testing.T) {
+	s := beginTxWithFixtures()
+	defer s.AutoRollback()
+
+	var upserted []Person
+	assert.Panics(t, func() {
+		s.Upsert("people").
+			Columns("id", "name").
+			Key("id").
+			Values(1, "Mario").
+			Values(1, "Luigi").
+			Returning("id", "name").
+			QueryStructs(&upserted)
+	})
+}
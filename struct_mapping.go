@@ -30,6 +30,13 @@ func valuesFor(recordType reflect.Type, record reflect.Value, columns []string)
 	return values, nil
 }
 
+// RecordValues returns the values of the fields of rec, a struct or pointer
+// to a struct, mapped to columns by their db tags.
+func RecordValues(rec interface{}, columns ...string) ([]interface{}, error) {
+	ind := reflect.Indirect(reflect.ValueOf(rec))
+	return valuesFor(ind.Type(), ind, columns)
+}
+
 func reflectColumns(v interface{}) []string {
 	cols := []string{}
 	for _, name := range reflectFields(v).DeclaredNames {
@@ -49,3 +56,17 @@ func reflectExcludeColumns(v interface{}, blacklist []string) []string {
 
 	return cols
 }
+
+// recordColumns returns the columns of rec to write: cols, the fields not
+// in cols if it is a blacklist, or all fields for "*".
+func recordColumns(rec interface{}, cols []string, isBlacklist bool) []string {
+	// reflect fields removing blacklisted columns
+	if isBlacklist {
+		cols = reflectExcludeColumns(rec, cols)
+	}
+	// reflect all fields
+	if len(cols) > 0 && cols[0] == "*" {
+		cols = reflectColumns(rec)
+	}
+	return cols
+}
@@ -42,3 +42,17 @@ func TestEmbeddedStructInvalidColumns(t *testing.T) {
 		InsertInto("groups").Columns("group_uuid", "realm_uuid").Record(g).ToSQL()
 	})
 }
+
+func TestRecordValues(t *testing.T) {
+	rec := someRecord{1, 88, true}
+	vals, err := RecordValues(&rec, "other", "something_id")
+	assert.NoError(t, err)
+	assert.Exactly(t, []interface{}{true, 1}, vals)
+
+	vals, err = RecordValues(rec, "user_id")
+	assert.NoError(t, err)
+	assert.Exactly(t, []interface{}{int64(88)}, vals)
+
+	_, err = RecordValues(rec, "missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package dat
+
+import (
+	"testing"
+
+	"gopkg.in/mgutz/dat.v1/mysql"
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+func TestSelectFromSub(t *testing.T) {
+	sub := Select("id", "user_id").From("posts").Where("state = $1", "published")
+	sql, args := Select("t.user_id", "count(*)").
+		FromSub(sub, "t").
+		Where("t.id > $1", 10).
+		GroupBy("t.user_id").
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT t.user_id, count(*)
+		FROM (SELECT id, user_id FROM posts WHERE (state = $1)) AS t
+		WHERE (t.id > $2)
+		GROUP BY t.user_id`), stripWS(sql))
+	assert.Exactly(t, []interface{}{"published", 10}, args)
+}
+
+func TestSelectWhereSub(t *testing.T) {
+	sub := Select("user_id").From("posts").Where("state = $1", "published")
+	sql, args := Select("id").
+		From("people").
+		Where("id IN $1 AND created_at > $2", sub, 100).
+		Having("count(*) > $1", 2).
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT id
+		FROM people
+		WHERE (id IN (SELECT user_id FROM posts WHERE (state = $2)) AND created_at > $1)
+		HAVING (count(*) > $3)`), stripWS(sql))
+	assert.Exactly(t, []interface{}{100, "published", 2}, args)
+}
+
+func TestSelectHavingSub(t *testing.T) {
+	avg := SQL("SELECT avg(amount) FROM orders WHERE region = $1", "west")
+	sql, args := Select("user_id").
+		From("orders").
+		GroupBy("user_id").
+		Having("sum(amount) > $1", avg).
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT user_id
+		FROM orders
+		GROUP BY user_id
+		HAVING (sum(amount) > (SELECT avg(amount) FROM orders WHERE region = $1))`), stripWS(sql))
+	assert.Exactly(t, []interface{}{"west"}, args)
+}
+
+func TestSelectSubQuestion(t *testing.T) {
+	sub := Select("user_id").From("posts").Where("state = $1", "published")
+	sql, args := Select("id").
+		FromSub(Select("*").From("people").Where("age > $1", 18), "p").
+		Where("id IN $1 AND created_at > $2", sub, 100).
+		SetDialect(mysql.New()).
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT id
+		FROM (SELECT * FROM people WHERE (age > ?)) AS p
+		WHERE (id IN (SELECT user_id FROM posts WHERE (state = ?)) AND created_at > ?)`), stripWS(sql))
+	assert.Exactly(t, []interface{}{18, "published", 100}, args)
+}
+
+func TestSelectDocFromSub(t *testing.T) {
+	sub := Select("id", "name").From("people").Where("id > $1", 1)
+	sql, args := SelectDoc("id", "name").
+		FromSub(sub, "p").
+		Where("name = $1", "mario").
+		ToSQL()
+	assert.Equal(t, stripWS(`
+		SELECT row_to_json(dat__item.*)
+		FROM (
+			SELECT id, name
+			FROM (SELECT id, name FROM people WHERE (id > $1)) AS p
+			WHERE (name = $2)
+		) as dat__item`), stripWS(sql))
+	assert.Exactly(t, []interface{}{1, "mario"}, args)
+}
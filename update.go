@@ -2,7 +2,6 @@ package dat
 
 import (
 	"reflect"
-	"strconv"
 )
 
 // UpdateBuilder contains the clauses for an UPDATE statement
@@ -10,8 +9,11 @@ type UpdateBuilder struct {
 	Execer
 
 	isInterpolated bool
+	dialect        SQLDialect
+	with           withClause
 	table          string
 	setClauses     []*setClause
+	fromFragments  []*whereFragment
 	whereFragments []*whereFragment
 	orderBys       []string
 	limitCount     uint64
@@ -36,16 +38,31 @@ func NewUpdateBuilder(table string) *UpdateBuilder {
 	return &UpdateBuilder{table: table, isInterpolated: EnableInterpolation}
 }
 
+// Clone returns a deep copy of the builder. Clauses added to the clone do
+// not change b, so a base query can be shared and specialized.
+func (b *UpdateBuilder) Clone() *UpdateBuilder {
+	clone := *b
+	clone.with = b.with.clone()
+	clone.setClauses = cloneSetClauses(b.setClauses)
+	clone.fromFragments = cloneFragments(b.fromFragments)
+	clone.whereFragments = cloneFragments(b.whereFragments)
+	clone.orderBys = cloneStrings(b.orderBys)
+	clone.returnings = cloneStrings(b.returnings)
+	clone.Execer = cloneExecer(b.Execer, &clone)
+	return &clone
+}
+
 // Set appends a column/value pair for the statement
 func (b *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
 	b.setClauses = append(b.setClauses, &setClause{column: column, value: value})
 	return b
 }
 
-// SetMap appends the elements of the map as column/value pairs for the statement
+// SetMap appends the elements of the map as column/value pairs for the
+// statement, sorted by column.
 func (b *UpdateBuilder) SetMap(clauses map[string]interface{}) *UpdateBuilder {
-	for col, val := range clauses {
-		b = b.Set(col, val)
+	for _, col := range sortedKeys(clauses) {
+		b = b.Set(col, clauses[col])
 	}
 	return b
 }
@@ -102,11 +119,37 @@ func (b *UpdateBuilder) ScopeMap(mapScope *MapScope, m M) *UpdateBuilder {
 // Scope uses a predefined scope in place of WHERE.
 func (b *UpdateBuilder) Scope(sql string, args ...interface{}) *UpdateBuilder {
 	b.scope = ScopeFunc(func(table string) (string, []interface{}) {
-		return escapeScopeTable(sql, table), args
+		return escapeScopeTable(b.sqlDialect(), sql, table), args
 	})
 	return b
 }
 
+// With adds a common table expression to the WITH clause of the statement.
+// sqlOrBuilder is a Builder or a SQL string with args.
+func (b *UpdateBuilder) With(name string, sqlOrBuilder interface{}, args ...interface{}) *UpdateBuilder {
+	b.with.add(name, false, sqlOrBuilder, args)
+	return b
+}
+
+// WithRecursive adds a common table expression which may reference itself
+// and marks the WITH clause RECURSIVE.
+func (b *UpdateBuilder) WithRecursive(name string, sqlOrBuilder interface{}, args ...interface{}) *UpdateBuilder {
+	b.with.add(name, true, sqlOrBuilder, args)
+	return b
+}
+
+// From appends tables to the FROM clause of the statement. Columns of the
+// tables may be used in Where and in Set through Expr.
+//
+//	Update("accounts").
+//		Set("balance", Expr("accounts.balance + t.amount")).
+//		From("transfers t").
+//		Where("t.account_id = accounts.id AND t.batch_id = $1", batchID)
+func (b *UpdateBuilder) From(tables string, args ...interface{}) *UpdateBuilder {
+	b.fromFragments = append(b.fromFragments, newWhereFragment(tables, args))
+	return b
+}
+
 // Where appends a WHERE clause to the statement
 func (b *UpdateBuilder) Where(whereSQLOrMap interface{}, args ...interface{}) *UpdateBuilder {
 	b.whereFragments = append(b.whereFragments, newWhereFragment(whereSQLOrMap, args))
@@ -139,9 +182,17 @@ func (b *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
 	return b
 }
 
+func (b *UpdateBuilder) checkDialect(d SQLDialect) error {
+	if len(b.fromFragments) > 0 && !d.SupportsUpdateFrom() {
+		return unsupported(msgUpdateFromUnsupported)
+	}
+	return checkReturning(d, b.returnings)
+}
+
 // ToSQL serialized the UpdateBuilder to a SQL string
 // It returns the string with placeholders and a slice of query arguments
 func (b *UpdateBuilder) ToSQL() (string, []interface{}) {
+	d := b.sqlDialect()
 	if len(b.table) == 0 {
 		panic("no table specified")
 	}
@@ -152,47 +203,46 @@ func (b *UpdateBuilder) ToSQL() (string, []interface{}) {
 	buf := bufPool.Get()
 	defer bufPool.Put(buf)
 	var args []interface{}
+	var placeholderStartPos int64 = 1
 
+	b.with.writeSQL(d, buf, &args, &placeholderStartPos)
 	buf.WriteString("UPDATE ")
-	writeIdentifier(buf, b.table)
+	writeIdentifier(d, buf, b.table)
 	buf.WriteString(" SET ")
 
-	var placeholderStartPos int64 = 1
-
 	// Build SET clause SQL with placeholders and add values to args
 	for i, c := range b.setClauses {
 		if i > 0 {
 			buf.WriteString(", ")
 		}
-		Dialect.WriteIdentifier(buf, c.column)
+		d.WriteIdentifier(buf, c.column)
 		if e, ok := c.value.(*Expression); ok {
-			start := placeholderStartPos
 			buf.WriteString(" = ")
 			// map relative $1, $2 placeholders to absolute
-			remapPlaceholders(buf, e.Sql, start)
-			args = append(args, e.Args...)
-			placeholderStartPos += int64(len(e.Args))
+			remapPlaceholders(d, buf, e.Sql, e.Args, &args, &placeholderStartPos)
 		} else {
-			// TOOD
-			if placeholderStartPos < maxLookup {
-				buf.WriteString(equalsPlaceholderTab[placeholderStartPos])
-			} else {
-				buf.WriteString(" = $")
-				buf.WriteString(strconv.FormatInt(placeholderStartPos, 10))
-			}
+			buf.WriteString(equalsPlaceholder(d, placeholderStartPos))
 			placeholderStartPos++
 			args = append(args, c.value)
 		}
 	}
 
+	if len(b.fromFragments) > 0 {
+		if !d.SupportsUpdateFrom() {
+			panic(msgUpdateFromUnsupported)
+		}
+		buf.WriteString(" FROM ")
+		writeCommaFragmentsToSQL(d, buf, b.fromFragments, &args, &placeholderStartPos)
+	}
+
 	if b.scope == nil {
 		if len(b.whereFragments) > 0 {
 			buf.WriteString(" WHERE ")
-			writeAndFragmentsToSQL(buf, b.whereFragments, &args, &placeholderStartPos)
+			writeAndFragmentsToSQL(d, buf, b.whereFragments, &args, &placeholderStartPos)
 		}
 	} else {
-		whereFragment := newWhereFragment(b.scope.ToSQL(b.table))
-		writeScopeCondition(buf, whereFragment, &args, &placeholderStartPos)
+		whereFragment := newWhereFragment(scopeToSQL(d, b.scope, b.table))
+		writeScopeCondition(d, buf, whereFragment, &args, &placeholderStartPos)
 	}
 
 	// Ordering and limiting
@@ -216,15 +266,7 @@ func (b *UpdateBuilder) ToSQL() (string, []interface{}) {
 		writeUint64(buf, b.offsetCount)
 	}
 
-	// Go thru the returning clauses
-	for i, c := range b.returnings {
-		if i == 0 {
-			buf.WriteString(" RETURNING ")
-		} else {
-			buf.WriteRune(',')
-		}
-		Dialect.WriteIdentifier(buf, c)
-	}
+	writeReturning(d, buf, b.returnings)
 
 	return buf.String(), args
 }
@@ -3,6 +3,7 @@ package dat
 import (
 	"testing"
 
+	"gopkg.in/mgutz/dat.v1/mysql"
 	"gopkg.in/stretchr/testify.v1/assert"
 	"fmt"
 	"strings"
@@ -37,13 +38,16 @@ func TestUpdateSingleToSql(t *testing.T) {
 }
 
 func TestUpdateSetMapToSql(t *testing.T) {
-	sql, args := Update("a").SetMap(map[string]interface{}{"b": 1, "c": 2}).Where("id = $1", 1).ToSQL()
+	sql, args := Update("a").SetMap(map[string]interface{}{"c": 2, "b": 1}).Where("id = $1", 1).ToSQL()
 
-	if sql == quoteSQL(`UPDATE "a" SET %s = $1, %s = $2 WHERE (id = $3)`, "b", "c") {
-		assert.Equal(t, []interface{}{1, 2, 1}, args)
-	} else {
-		assert.Equal(t, quoteSQL(`UPDATE "a" SET %s = $1, %s = $2 WHERE (id = $3)`, "c", "b"), sql)
-		assert.Equal(t, []interface{}{2, 1, 1}, args)
+	assert.Equal(t, quoteSQL(`UPDATE "a" SET %s = $1, %s = $2 WHERE (id = $3)`, "b", "c"), sql)
+	assert.Equal(t, []interface{}{1, 2, 1}, args)
+
+	m := map[string]interface{}{"e": 5, "b": 2, "d": 4, "a": 1, "c": 3}
+	for i := 0; i < 20; i++ {
+		sql, args = Update("a").SetMap(m).ToSQL()
+		assert.Equal(t, quoteSQL(`UPDATE "a" SET %s = $1, %s = $2, %s = $3, %s = $4, %s = $5`, "a", "b", "c", "d", "e"), sql)
+		assert.Equal(t, []interface{}{1, 2, 3, 4, 5}, args)
 	}
 }
 
@@ -115,3 +119,29 @@ func TestUpdateBeyondMaxLookup(t *testing.T) {
 	assert.Equal(t, expectedArgs, args)
 
 }
+
+func TestUpdateFromToSql(t *testing.T) {
+	sql, args := Update("accounts").
+		Set("balance", Expr("accounts.balance + t.amount")).
+		Set("updated_by", 7).
+		From("transfers t").
+		From("(VALUES ($1, $2)) AS v(id, note)", 1, "fix").
+		Where("t.account_id = accounts.id AND v.id = t.id AND t.batch_id = $1", 9).
+		ToSQL()
+
+	assert.Equal(t, `UPDATE "accounts" SET "balance" = accounts.balance + t.amount, "updated_by" = $1 FROM transfers t, (VALUES ($2, $3)) AS v(id, note) WHERE (t.account_id = accounts.id AND v.id = t.id AND t.batch_id = $4)`, sql)
+	assert.Equal(t, []interface{}{7, 1, "fix", 9}, args)
+}
+
+func TestUpdateFromUnsupported(t *testing.T) {
+	b := Update("accounts").
+		Set("balance", Expr("accounts.balance + t.amount")).
+		From("transfers t").
+		Where("t.account_id = accounts.id").
+		SetDialect(mysql.New())
+	assert.Panics(t, func() {
+		b.ToSQL()
+	})
+	_, _, err := b.Interpolate()
+	assert.Equal(t, ErrInvalidOperation, err)
+}
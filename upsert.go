@@ -1,17 +1,24 @@
 package dat
 
-import "reflect"
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+)
 
 // UpsertBuilder contains the clauses for an INSERT statement
 type UpsertBuilder struct {
 	Execer
 
 	isInterpolated bool
+	dialect        SQLDialect
 	table          string
 	cols           []string
 	isBlacklist    bool
-	vals           []interface{}
-	record         interface{}
+	keys           []string
+	vals           [][]interface{}
+	records        []interface{}
 	returnings     []string
 	whereFragments []*whereFragment
 }
@@ -25,6 +32,20 @@ func NewUpsertBuilder(table string) *UpsertBuilder {
 	return &UpsertBuilder{table: table, isInterpolated: EnableInterpolation}
 }
 
+// Clone returns a deep copy of the builder. Clauses added to the clone do
+// not change b, so a base query can be shared and specialized.
+func (b *UpsertBuilder) Clone() *UpsertBuilder {
+	clone := *b
+	clone.cols = cloneStrings(b.cols)
+	clone.keys = cloneStrings(b.keys)
+	clone.vals = cloneRows(b.vals)
+	clone.records = cloneRecords(b.records)
+	clone.returnings = cloneStrings(b.returnings)
+	clone.whereFragments = cloneFragments(b.whereFragments)
+	clone.Execer = cloneExecer(b.Execer, &clone)
+	return &clone
+}
+
 // Columns appends columns to insert in the statement
 func (b *UpsertBuilder) Columns(columns ...string) *UpsertBuilder {
 	return b.Whitelist(columns...)
@@ -45,15 +66,37 @@ func (b *UpsertBuilder) Whitelist(columns ...string) *UpsertBuilder {
 	return b
 }
 
+// Key sets the columns which identify a row. Rows matching the key columns
+// are updated, the others are inserted. Key is required for multiple rows
+// and cannot be used with Where. Each row must have a different key. Dialects
+// without writable CTEs use INSERT ... ON CONFLICT or ON DUPLICATE KEY UPDATE,
+// which require a unique index on the key columns.
+func (b *UpsertBuilder) Key(columns ...string) *UpsertBuilder {
+	b.keys = columns
+	return b
+}
+
 // Values appends a set of values to the statement
 func (b *UpsertBuilder) Values(vals ...interface{}) *UpsertBuilder {
-	b.vals = vals
+	b.vals = append(b.vals, vals)
 	return b
 }
 
 // Record pulls in values to match Columns from the record
 func (b *UpsertBuilder) Record(record interface{}) *UpsertBuilder {
-	b.record = record
+	b.records = append(b.records, record)
+	return b
+}
+
+// Records pulls in values to match Columns from each record of a slice
+func (b *UpsertBuilder) Records(records interface{}) *UpsertBuilder {
+	v := reflect.Indirect(reflect.ValueOf(records))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		panic("Records requires a slice of records")
+	}
+	for i := 0; i < v.Len(); i++ {
+		b.records = append(b.records, v.Index(i).Interface())
+	}
 	return b
 }
 
@@ -63,89 +106,85 @@ func (b *UpsertBuilder) Returning(columns ...string) *UpsertBuilder {
 	return b
 }
 
+func (b *UpsertBuilder) checkDialect(d SQLDialect) error {
+	if len(b.keys) == 0 && !d.SupportsWritableCTE() {
+		return unsupported(msgUpsertWhereUnsupported)
+	}
+	return checkReturning(d, b.returnings)
+}
+
 // ToSQL serialized the UpsertBuilder to a SQL string
 // It returns the string with placeholders and a slice of query arguments
 func (b *UpsertBuilder) ToSQL() (string, []interface{}) {
+	d := b.sqlDialect()
 	if len(b.table) == 0 {
 		panic("no table specified")
 	}
 	lenCols := len(b.cols)
+	lenRecords := len(b.records)
 	if lenCols == 0 {
 		panic("no columns specified")
 	}
-	if len(b.vals) == 0 && b.record == nil {
+	if len(b.vals) == 0 && lenRecords == 0 {
 		panic("no values or records specified")
 	}
 
-	if b.record == nil && b.cols[0] == "*" {
+	if lenRecords == 0 && b.cols[0] == "*" {
 		panic(`"*" can only be used in conjunction with Record`)
 	}
-	if b.record == nil && b.isBlacklist {
+	if lenRecords == 0 && b.isBlacklist {
 		panic(`Blacklist can only be used in conjunction with Record`)
 	}
-	// build where clause from columns and values
-	if len(b.whereFragments) == 0 {
-		panic("where clause required for upsert")
+	if len(b.keys) == 0 {
+		// build where clause from columns and values
+		if len(b.whereFragments) == 0 {
+			panic("where clause required for upsert")
+		}
+		if len(b.vals)+lenRecords > 1 {
+			panic("Key is required to upsert multiple rows")
+		}
+	} else if len(b.whereFragments) > 0 {
+		panic("Key and Where cannot be used together")
 	}
 
-	// reflect fields removing blacklisted columns
-	if b.record != nil && b.isBlacklist {
-		b.cols = reflectExcludeColumns(b.record, b.cols)
-	}
-	// reflect all fields
-	if b.record != nil && b.cols[0] == "*" {
-		b.cols = reflectColumns(b.record)
+	if lenRecords > 0 {
+		// resolve columns on a copy, ToSQL does not modify the builder
+		c := *b
+		c.cols = recordColumns(b.records[0], b.cols, b.isBlacklist)
+		b = &c
 	}
 
-	if len(b.returnings) == 0 {
-		b.returnings = b.cols
+	returnings := b.returnings
+	if len(returnings) == 0 {
+		returnings = b.cols
 	}
 
-	/*
-					   END GOAL:
-
-				WITH
-					new_values (id, field1, field2) AS (
-						values (1, 'A', 'X'),
-							   (2, 'B', 'Y'),
-							   (3, 'C', 'Z')
-					),
-					upsert as
-					(
-						update mytable m
-							set field1 = nv.field1,
-								field2 = nv.field2
-						FROM new_values nv
-						WHERE m.id = nv.id
-						RETURNING m.*
-					)
-				INSERT INTO mytable (id, field1, field2)
-				SELECT id, field1, field2
-				FROM new_values
-				WHERE NOT EXISTS (SELECT 1
-				                  FROM upsert up
-				                  WHERE up.id = new_values.id)
-
-
-
-
-
-
-		Upsert("table").
-			Columns("name", "email").
-			Values("mario", "mario@barc.com").
-			Where("name = $1", "mario").
-			Returning("id", "name", "email")
-	*/
-	if b.record != nil {
-		ind := reflect.Indirect(reflect.ValueOf(b.record))
-		var err error
-		b.vals, err = valuesFor(ind.Type(), ind, b.cols)
+	rows := make([][]interface{}, 0, len(b.vals)+lenRecords)
+	rows = append(rows, b.vals...)
+	for _, rec := range b.records {
+		ind := reflect.Indirect(reflect.ValueOf(rec))
+		vals, err := valuesFor(ind.Type(), ind, b.cols)
 		if err != nil {
 			panic(err.Error())
 		}
+		rows = append(rows, vals)
 	}
 
+	if len(b.keys) > 0 {
+		b.checkUniqueKeys(rows)
+		if d.SupportsWritableCTE() {
+			return b.toValuesSQL(rows, returnings)
+		}
+		if !d.SupportsOnConflict() {
+			return b.toDuplicateKeySQL(rows)
+		}
+		return b.toConflictSQL(rows, returnings)
+	}
+	if !d.SupportsWritableCTE() {
+		panic(msgUpsertWhereUnsupported)
+	}
+
+	vals := rows[0]
 	buf := bufPool.Get()
 	defer bufPool.Put(buf)
 
@@ -175,33 +214,288 @@ func (b *UpsertBuilder) ToSQL() (string, []interface{}) {
 
 	buf.WriteString("WITH upd AS ( ")
 
-	ub := NewUpdateBuilder(b.table)
+	ub := NewUpdateBuilder(b.table).SetDialect(d)
 	for i, col := range b.cols {
-		ub.Set(col, b.vals[i])
+		ub.Set(col, vals[i])
 	}
 	ub.whereFragments = b.whereFragments
-	ub.returnings = b.returnings
+	ub.returnings = returnings
 	updateSQL, args := ub.ToSQL()
 	buf.WriteString(updateSQL)
 
 	buf.WriteString("), ins AS (")
 
 	buf.WriteString(" INSERT INTO ")
-	writeIdentifier(buf, b.table)
+	writeIdentifier(d, buf, b.table)
 	buf.WriteString("(")
-	writeIdentifiers(buf, b.cols, ",")
+	writeIdentifiers(d, buf, b.cols, ",")
 	buf.WriteString(") SELECT ")
 
-	writePlaceholders(buf, len(b.vals), ",", 1)
+	writeReusedPlaceholders(d, buf, &args, len(vals), ",", 1)
 
-	buf.WriteString(" WHERE NOT EXISTS (SELECT 1 FROM upd) RETURNING ")
-	writeIdentifiers(buf, b.returnings, ",")
+	buf.WriteString(" WHERE NOT EXISTS (SELECT 1 FROM upd)")
+	writeReturning(d, buf, returnings)
 
 	buf.WriteString(") SELECT * FROM ins UNION ALL SELECT * FROM upd")
 
 	return buf.String(), args
 }
 
+// updateColumns returns the columns which are not keys. It panics if a key
+// is not a column.
+func (b *UpsertBuilder) updateColumns() []string {
+	for _, key := range b.keys {
+		found := false
+		for _, col := range b.cols {
+			if col == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			panic("Key column " + key + " is not in Columns")
+		}
+	}
+
+	var cols []string
+	for _, col := range b.cols {
+		isKey := false
+		for _, key := range b.keys {
+			if col == key {
+				isKey = true
+				break
+			}
+		}
+		if !isKey {
+			cols = append(cols, col)
+		}
+	}
+	if len(cols) == 0 {
+		panic("Upsert requires a column which is not a Key")
+	}
+	return cols
+}
+
+// checkUniqueKeys panics if two rows have the same key, which of them is
+// upserted would be undefined.
+func (b *UpsertBuilder) checkUniqueKeys(rows [][]interface{}) {
+	if len(rows) < 2 {
+		return
+	}
+
+	keyIndexes := make([]int, len(b.keys))
+	for i, key := range b.keys {
+		keyIndexes[i] = -1
+		for j, col := range b.cols {
+			if col == key {
+				keyIndexes[i] = j
+				break
+			}
+		}
+		if keyIndexes[i] < 0 {
+			panic("Key column " + key + " is not in Columns")
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, row := range rows {
+		if len(row) != len(b.cols) {
+			panic("number of values does not match number of columns")
+		}
+		var buf bytes.Buffer
+		for _, i := range keyIndexes {
+			fmt.Fprintf(&buf, "%#v\x00", keyValue(row[i]))
+		}
+		k := buf.String()
+		if seen[k] {
+			panic("Upsert requires a different Key for each row")
+		}
+		seen[k] = true
+	}
+}
+
+// keyValue returns the value of v compared to find rows with the same key.
+func keyValue(v interface{}) interface{} {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if val, err := valuer.Value(); err == nil {
+			v = val
+		}
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		return rv.Elem().Interface()
+	}
+	return v
+}
+
+// toValuesSQL writes the upsert of multiple rows keyed on Key. Rows are
+// updated from new_values where the key columns match, the others are
+// inserted.
+//
+//	WITH
+//		new_values AS (
+//			SELECT "id","name" FROM "people" WHERE false
+//			UNION ALL SELECT $1,$2
+//			UNION ALL SELECT $3,$4
+//		),
+//		upd AS (
+//			UPDATE "people" SET "name" = new_values."name"
+//			FROM new_values
+//			WHERE "people"."id" = new_values."id"
+//			RETURNING "people"."id","people"."name"
+//		),
+//		ins AS (
+//			INSERT INTO "people" ("id","name")
+//			SELECT "id","name" FROM new_values
+//			WHERE NOT EXISTS (SELECT 1 FROM "people" WHERE "people"."id" = new_values."id")
+//			RETURNING "id","name"
+//		)
+//	SELECT * FROM upd UNION ALL SELECT * FROM ins
+func (b *UpsertBuilder) toValuesSQL(rows [][]interface{}, returnings []string) (string, []interface{}) {
+	d := b.sqlDialect()
+	setCols := b.updateColumns()
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+
+	var args []interface{}
+	buf.WriteString("WITH new_values AS (")
+	writeNewValues(d, buf, b.table, b.cols, rows, false, &args)
+
+	buf.WriteString("), upd AS (UPDATE ")
+	writeIdentifier(d, buf, b.table)
+	buf.WriteString(" SET ")
+	for i, col := range setCols {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		writeIdentifier(d, buf, col)
+		buf.WriteString(" = new_values.")
+		writeIdentifier(d, buf, col)
+	}
+	buf.WriteString(" FROM new_values WHERE ")
+	writeKeysMatch(d, buf, b.table, "new_values", b.keys)
+	buf.WriteString(" RETURNING ")
+	for i, col := range returnings {
+		if i > 0 {
+			buf.WriteRune(',')
+		}
+		writeIdentifier(d, buf, b.table)
+		buf.WriteRune('.')
+		writeIdentifier(d, buf, col)
+	}
+
+	buf.WriteString("), ins AS (INSERT INTO ")
+	writeIdentifier(d, buf, b.table)
+	buf.WriteString(" (")
+	writeIdentifiers(d, buf, b.cols, ",")
+	buf.WriteString(") SELECT ")
+	writeIdentifiers(d, buf, b.cols, ",")
+	buf.WriteString(" FROM new_values WHERE NOT EXISTS (SELECT 1 FROM ")
+	writeIdentifier(d, buf, b.table)
+	buf.WriteString(" WHERE ")
+	writeKeysMatch(d, buf, b.table, "new_values", b.keys)
+	buf.WriteRune(')')
+	writeReturning(d, buf, returnings)
+
+	buf.WriteString(") SELECT * FROM upd UNION ALL SELECT * FROM ins")
+
+	return buf.String(), args
+}
+
+// toConflictSQL writes the upsert with Key for dialects without writable
+// CTEs. The key columns are the conflict target and must have a unique index.
+//
+//	INSERT INTO people (id, name)
+//	VALUES ($1, $2), ($3, $4)
+//	ON CONFLICT (id) DO UPDATE
+//	SET name = EXCLUDED.name
+//	RETURNING id, name
+func (b *UpsertBuilder) toConflictSQL(rows [][]interface{}, returnings []string) (string, []interface{}) {
+	d := b.sqlDialect()
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+
+	var args []interface{}
+
+	buf.WriteString("INSERT INTO ")
+	writeIdentifier(d, buf, b.table)
+	buf.WriteString(" (")
+	writeIdentifiers(d, buf, b.cols, ",")
+	buf.WriteString(") VALUES ")
+	start := 1
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteRune(',')
+		}
+		buildPlaceholders(d, buf, start, len(row))
+		args = append(args, row...)
+		start += len(row)
+	}
+	pos := int64(start)
+
+	conflict := &conflictClause{columns: b.keys}
+	for _, col := range b.updateColumns() {
+		conflict.setClauses = append(conflict.setClauses, &setClause{column: col, value: excluded(col)})
+	}
+	conflict.writeSQL(d, buf, &args, &pos)
+	writeReturning(d, buf, returnings)
+
+	return buf.String(), args
+}
+
+// toDuplicateKeySQL writes the upsert for dialects without ON CONFLICT, such
+// as MySQL. A row which violates any unique index is updated with the values
+// of the non key columns, so the key columns must have a unique index. There
+// is no RETURNING, the statement is executed with Exec.
+//
+//	INSERT INTO people (id, name)
+//	VALUES (?, ?), (?, ?)
+//	ON DUPLICATE KEY UPDATE name = VALUES(name)
+func (b *UpsertBuilder) toDuplicateKeySQL(rows [][]interface{}) (string, []interface{}) {
+	d := b.sqlDialect()
+	if len(b.keys) == 0 {
+		panic(msgUpsertWhereUnsupported)
+	}
+	if len(b.returnings) > 0 {
+		panic(msgReturningUnsupported)
+	}
+	setCols := b.updateColumns()
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+
+	var args []interface{}
+
+	buf.WriteString("INSERT INTO ")
+	writeIdentifier(d, buf, b.table)
+	buf.WriteString(" (")
+	writeIdentifiers(d, buf, b.cols, ",")
+	buf.WriteString(") VALUES ")
+	start := 1
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteRune(',')
+		}
+		buildPlaceholders(d, buf, start, len(row))
+		args = append(args, row...)
+		start += len(row)
+	}
+
+	buf.WriteString(" ON DUPLICATE KEY UPDATE ")
+	for i, col := range setCols {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		writeIdentifier(d, buf, col)
+		buf.WriteString(" = VALUES(")
+		writeIdentifier(d, buf, col)
+		buf.WriteRune(')')
+	}
+
+	return buf.String(), args
+}
+
 // Where appends a WHERE clause to the statement for the given string and args
 // or map of column/value pairs
 func (b *UpsertBuilder) Where(whereSQLOrMap interface{}, args ...interface{}) *UpsertBuilder {
@@ -3,6 +3,8 @@ package dat
 import (
 	"testing"
 
+	"gopkg.in/mgutz/dat.v1/mysql"
+	"gopkg.in/mgutz/dat.v1/sqlite"
 	"gopkg.in/stretchr/testify.v1/assert"
 )
 
@@ -88,3 +90,156 @@ func TestUpsertSQLRecord(t *testing.T) {
 	assert.Equal(t, stripWS(expected), stripWS(sql))
 	assert.Equal(t, []interface{}{1, 2, 4}, args)
 }
+
+func TestUpsertSQLWhereUnsupported(t *testing.T) {
+	b := Upsert("tab").Columns("b", "c").Values(1, 2).Where("d=$1", 4).SetDialect(sqlite.New())
+	assert.Panics(t, func() {
+		b.ToSQL()
+	})
+	_, _, err := b.Interpolate()
+	assert.Equal(t, ErrInvalidOperation, err)
+}
+
+func TestUpsertSQLKeyRequired(t *testing.T) {
+	assert.Panics(t, func() {
+		Upsert("tab").Columns("b", "c").Values(1, 2).Values(3, 4).Where("d=$1", 4).ToSQL()
+	})
+	assert.Panics(t, func() {
+		Upsert("tab").Columns("b", "c").Values(1, 2).Key("b").Where("d=$1", 4).ToSQL()
+	})
+	assert.Panics(t, func() {
+		Upsert("tab").Columns("b", "c").Values(1, 2).Key("d").ToSQL()
+	})
+	assert.Panics(t, func() {
+		Upsert("tab").Columns("b").Values(1).Key("b").ToSQL()
+	})
+}
+
+func TestUpsertSQLMultiple(t *testing.T) {
+	type rec struct {
+		B int `db:"b"`
+		C int `db:"c"`
+	}
+
+	sql, args := Upsert("tab").
+		Columns("b", "c").
+		Key("b").
+		Values(1, 2).
+		Records([]rec{{3, 4}, {5, 6}}).
+		Returning("id", "c").
+		ToSQL()
+
+	expected := `
+	WITH
+		new_values AS (
+			SELECT "b","c" FROM "tab" WHERE false
+			UNION ALL SELECT $1,$2
+			UNION ALL SELECT $3,$4
+			UNION ALL SELECT $5,$6
+		), upd AS (
+			UPDATE "tab" SET "c" = new_values."c"
+			FROM new_values
+			WHERE "tab"."b" = new_values."b"
+			RETURNING "tab"."id","tab"."c"
+		), ins AS (
+			INSERT INTO "tab" ("b","c")
+			SELECT "b","c" FROM new_values
+			WHERE NOT EXISTS (SELECT 1 FROM "tab" WHERE "tab"."b" = new_values."b")
+			RETURNING "id","c"
+		)
+	SELECT * FROM upd UNION ALL SELECT * FROM ins
+	`
+
+	assert.Equal(t, stripWS(expected), stripWS(sql))
+	assert.Equal(t, []interface{}{1, 2, 3, 4, 5, 6}, args)
+}
+
+func TestUpsertSQLMultipleDuplicateKeys(t *testing.T) {
+	b := 1
+	assert.Panics(t, func() {
+		Upsert("tab").
+			Columns("a", "b", "c").
+			Key("a", "b").
+			Values(1, 1, "first").
+			Values(1, &b, "second").
+			ToSQL()
+	})
+	assert.Panics(t, func() {
+		Upsert("tab").
+			Columns("a", "b", "c").
+			Key("a").
+			Values(1, 1, "first").
+			Values(NullInt64From(1), 2, "second").
+			SetDialect(sqlite.New()).
+			ToSQL()
+	})
+
+	// keys differ in any column
+	assert.NotPanics(t, func() {
+		Upsert("tab").
+			Columns("a", "b", "c").
+			Key("a", "b").
+			Values(1, 1, "first").
+			Values(1, 2, "second").
+			ToSQL()
+	})
+}
+
+func TestUpsertSQLMultipleConflict(t *testing.T) {
+	sql, args := Upsert("tab").
+		Columns("a", "b", "c").
+		Key("a", "b").
+		Values(1, 2, 3).
+		Values(4, 5, 6).
+		SetDialect(sqlite.New()).
+		ToSQL()
+
+	expected := `
+	INSERT INTO "tab" ("a","b","c")
+	VALUES (?,?,?),(?,?,?)
+	ON CONFLICT ("a","b") DO UPDATE
+	SET "c" = EXCLUDED."c"
+	RETURNING "a","b","c"
+	`
+
+	assert.Equal(t, stripWS(expected), stripWS(sql))
+	assert.Equal(t, []interface{}{1, 2, 3, 4, 5, 6}, args)
+}
+
+func TestUpsertSQLDuplicateKey(t *testing.T) {
+	type rec struct {
+		A int `db:"a"`
+		B int `db:"b"`
+		C int `db:"c"`
+	}
+
+	sql, args := Upsert("tab").
+		Columns("a", "b", "c").
+		Key("a").
+		Values(1, 2, 3).
+		Record(rec{4, 5, 6}).
+		SetDialect(mysql.New()).
+		ToSQL()
+
+	expected := "INSERT INTO `tab` (`a`,`b`,`c`) VALUES (?,?,?),(?,?,?) " +
+		"ON DUPLICATE KEY UPDATE `b` = VALUES(`b`), `c` = VALUES(`c`)"
+
+	assert.Equal(t, expected, sql)
+	assert.Equal(t, []interface{}{1, 2, 3, 4, 5, 6}, args)
+}
+
+func TestUpsertSQLDuplicateKeyUnsupported(t *testing.T) {
+	where := Upsert("tab").Columns("b", "c").Values(1, 2).Where("d=$1", 4).SetDialect(mysql.New())
+	assert.Panics(t, func() {
+		where.ToSQL()
+	})
+	_, _, err := where.Interpolate()
+	assert.Equal(t, ErrInvalidOperation, err)
+
+	returning := Upsert("tab").Columns("b", "c").Values(1, 2).Key("b").Returning("id").SetDialect(mysql.New())
+	assert.Panics(t, func() {
+		returning.ToSQL()
+	})
+	_, _, err = returning.Interpolate()
+	assert.Equal(t, ErrInvalidOperation, err)
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -87,15 +88,6 @@ func writeUint64(buf common.BufferWriter, n uint64) {
 	}
 }
 
-func writePlaceholder(buf common.BufferWriter, pos int) {
-	if pos < maxLookup {
-		buf.WriteString(placeholderTab[pos])
-	} else {
-		buf.WriteRune('$')
-		buf.WriteString(strconv.Itoa(pos))
-	}
-}
-
 // SQLMapFromReader creates a SQL map from an io.Reader.
 //
 // This string
@@ -292,3 +284,13 @@ func ParseDir(dir string, version string) error {
 
 	return filepath.Walk(dir, walkFn)
 }
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,9 +2,6 @@ package dat
 
 import (
 	"reflect"
-	"regexp"
-	"strconv"
-	"strings"
 
 	"gopkg.in/mgutz/dat.v1/common"
 )
@@ -16,6 +13,7 @@ type whereFragment struct {
 	Condition   string
 	Values      []interface{}
 	EqualityMap map[string]interface{}
+	Predicate   Predicate
 }
 
 func newWhereFragment(whereSQLOrMap interface{}, args []interface{}) *whereFragment {
@@ -30,58 +28,34 @@ func newWhereFragment(whereSQLOrMap interface{}, args []interface{}) *whereFragm
 		return &whereFragment{EqualityMap: pred}
 	case Eq:
 		return &whereFragment{EqualityMap: map[string]interface{}(pred)}
+	case Predicate:
+		return &whereFragment{Predicate: pred}
 	default:
-		panic("Invalid argument passed to Where. Pass a string or an Eq map.")
+		panic("Invalid argument passed to Where. Pass a string, an Eq map or a Predicate.")
 	}
 }
 
-var rePlaceholder = regexp.MustCompile(`\$\d+`)
-
-func remapPlaceholders(buf common.BufferWriter, statement string, start int64) int64 {
-	if !strings.Contains(statement, "$") {
-		buf.WriteString(statement)
-		return 0
-	}
-
-	highest := 0
-	pos := int(start) - 1 // 0-based
-	statement = rePlaceholder.ReplaceAllStringFunc(statement, func(s string) string {
-		i, _ := strconv.Atoi(s[1:])
-		if i > highest {
-			highest = i
-		}
-
-		sum := strconv.Itoa(pos + i)
-		return "$" + sum
-	})
-
-	buf.WriteString(statement)
-	return int64(highest)
-}
-
 // Invariant: for scope conditions only
-func writeScopeCondition(buf common.BufferWriter, f *whereFragment, args *[]interface{}, pos *int64) {
+func writeScopeCondition(d SQLDialect, buf common.BufferWriter, f *whereFragment, args *[]interface{}, pos *int64) {
 	buf.WriteRune(' ')
 	if len(f.Values) > 0 {
 		// map relative $1, $2 placeholders to absolute
-		replaced := remapPlaceholders(buf, f.Condition, *pos)
-		*pos += replaced
-		*args = append(*args, f.Values...)
+		remapPlaceholders(d, buf, f.Condition, f.Values, args, pos)
 	} else {
 		buf.WriteString(f.Condition)
 	}
 }
 
-func writeAndFragmentsToSQL(buf common.BufferWriter, fragments []*whereFragment, args *[]interface{}, pos *int64) {
-	writeFragmentsToSQL(" AND ", true, buf, fragments, args, pos)
+func writeAndFragmentsToSQL(d SQLDialect, buf common.BufferWriter, fragments []*whereFragment, args *[]interface{}, pos *int64) {
+	writeFragmentsToSQL(d, " AND ", true, buf, fragments, args, pos)
 }
 
-func writeCommaFragmentsToSQL(buf common.BufferWriter, fragments []*whereFragment, args *[]interface{}, pos *int64) {
-	writeFragmentsToSQL(", ", false, buf, fragments, args, pos)
+func writeCommaFragmentsToSQL(d SQLDialect, buf common.BufferWriter, fragments []*whereFragment, args *[]interface{}, pos *int64) {
+	writeFragmentsToSQL(d, ", ", false, buf, fragments, args, pos)
 }
 
 // Invariant: only called when len(fragments) > 0
-func writeFragmentsToSQL(delimiter string, addParens bool, buf common.BufferWriter, fragments []*whereFragment, args *[]interface{}, pos *int64) {
+func writeFragmentsToSQL(d SQLDialect, delimiter string, addParens bool, buf common.BufferWriter, fragments []*whereFragment, args *[]interface{}, pos *int64) {
 	hasConditions := false
 	for _, f := range fragments {
 		if f.Condition != "" {
@@ -97,9 +71,7 @@ func writeFragmentsToSQL(delimiter string, addParens bool, buf common.BufferWrit
 
 			if len(f.Values) > 0 {
 				// map relative $1, $2 placeholders to absolute
-				replaced := remapPlaceholders(buf, f.Condition, *pos)
-				*pos += replaced
-				*args = append(*args, f.Values...)
+				remapPlaceholders(d, buf, f.Condition, f.Values, args, pos)
 			} else {
 				buf.WriteString(f.Condition)
 			}
@@ -107,17 +79,27 @@ func writeFragmentsToSQL(delimiter string, addParens bool, buf common.BufferWrit
 				buf.WriteRune(')')
 			}
 		} else if f.EqualityMap != nil {
-			hasConditions = writeEqualityMapToSQL(buf, f.EqualityMap, args, hasConditions, pos)
+			hasConditions = writeEqualityMapToSQL(d, buf, f.EqualityMap, args, hasConditions, pos)
+		} else if f.Predicate != nil {
+			if hasConditions {
+				buf.WriteString(delimiter)
+			} else {
+				hasConditions = true
+			}
+			f.Predicate.writePredicate(d, buf, args, pos)
 		} else {
 			panic("invalid equality map")
 		}
 	}
 }
 
-func writeEqualityMapToSQL(buf common.BufferWriter, eq map[string]interface{}, args *[]interface{}, anyConditions bool, pos *int64) bool {
-	for k, v := range eq {
+// writeEqualityMapToSQL writes the conditions of eq sorted by column, so the
+// SQL is the same for equal maps.
+func writeEqualityMapToSQL(d SQLDialect, buf common.BufferWriter, eq map[string]interface{}, args *[]interface{}, anyConditions bool, pos *int64) bool {
+	for _, k := range sortedKeys(eq) {
+		v := eq[k]
 		if v == nil {
-			anyConditions = writeWhereCondition(buf, k, " IS NULL", anyConditions)
+			anyConditions = writeWhereCondition(d, buf, k, " IS NULL", anyConditions)
 		} else {
 			vVal := reflect.ValueOf(v)
 
@@ -125,7 +107,7 @@ func writeEqualityMapToSQL(buf common.BufferWriter, eq map[string]interface{}, a
 				vValLen := vVal.Len()
 				if vValLen == 0 {
 					if vVal.IsNil() {
-						anyConditions = writeWhereCondition(buf, k, " IS NULL", anyConditions)
+						anyConditions = writeWhereCondition(d, buf, k, " IS NULL", anyConditions)
 					} else {
 						if anyConditions {
 							buf.WriteString(" AND (1=0)")
@@ -134,17 +116,17 @@ func writeEqualityMapToSQL(buf common.BufferWriter, eq map[string]interface{}, a
 						}
 					}
 				} else if vValLen == 1 {
-					anyConditions = writeWhereCondition(buf, k, equalsPlaceholderTab[*pos], anyConditions)
+					anyConditions = writeWhereCondition(d, buf, k, equalsPlaceholder(d, *pos), anyConditions)
 					*args = append(*args, vVal.Index(0).Interface())
 					*pos++
 				} else {
 					// " IN $n"
-					anyConditions = writeWhereCondition(buf, k, inPlaceholderTab[*pos], anyConditions)
+					anyConditions = writeWhereCondition(d, buf, k, inPlaceholder(d, *pos), anyConditions)
 					*args = append(*args, v)
 					*pos++
 				}
 			} else {
-				anyConditions = writeWhereCondition(buf, k, equalsPlaceholderTab[*pos], anyConditions)
+				anyConditions = writeWhereCondition(d, buf, k, equalsPlaceholder(d, *pos), anyConditions)
 				*args = append(*args, v)
 				*pos++
 			}
@@ -154,14 +136,14 @@ func writeEqualityMapToSQL(buf common.BufferWriter, eq map[string]interface{}, a
 	return anyConditions
 }
 
-func writeWhereCondition(buf common.BufferWriter, k string, pred string, anyConditions bool) bool {
+func writeWhereCondition(d SQLDialect, buf common.BufferWriter, k string, pred string, anyConditions bool) bool {
 	if anyConditions {
 		buf.WriteString(" AND (")
 	} else {
 		buf.WriteRune('(')
 		anyConditions = true
 	}
-	Dialect.WriteIdentifier(buf, k)
+	d.WriteIdentifier(buf, k)
 	buf.WriteString(pred)
 	buf.WriteRune(')')
 
@@ -0,0 +1,62 @@
+package dat
+
+import "gopkg.in/mgutz/dat.v1/common"
+
+// commonTableExpr is a named query of a WITH clause.
+type commonTableExpr struct {
+	name    string
+	builder Builder
+	expr    *Expression
+}
+
+// withClause contains the common table expressions of a statement.
+type withClause struct {
+	isRecursive bool
+	ctes        []*commonTableExpr
+}
+
+func (w *withClause) add(name string, recursive bool, sqlOrBuilder interface{}, args []interface{}) {
+	if name == "" {
+		panic("With requires a name")
+	}
+
+	cte := &commonTableExpr{name: name}
+	switch t := sqlOrBuilder.(type) {
+	default:
+		panic("sqlOrBuilder accepts only {string, Builder} type")
+	case Builder:
+		cte.builder = t
+	case string:
+		cte.expr = Expr(t, args...)
+	}
+	w.isRecursive = w.isRecursive || recursive
+	w.ctes = append(w.ctes, cte)
+}
+
+// writeSQL writes the WITH clause, followed by a space, if there are any
+// common table expressions. Placeholders are numbered from pos.
+func (w *withClause) writeSQL(d SQLDialect, buf common.BufferWriter, args *[]interface{}, pos *int64) {
+	if len(w.ctes) == 0 {
+		return
+	}
+
+	buf.WriteString("WITH ")
+	if w.isRecursive {
+		buf.WriteString("RECURSIVE ")
+	}
+	for i, cte := range w.ctes {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(cte.name)
+		buf.WriteString(" AS (")
+		if cte.builder != nil {
+			sql, values := builderSQL(d, cte.builder)
+			remapPlaceholders(d, buf, sql, values, args, pos)
+		} else {
+			cte.expr.writeRelativeArgs(d, buf, args, pos)
+		}
+		buf.WriteRune(')')
+	}
+	buf.WriteRune(' ')
+}
@@ -0,0 +1,105 @@
+package dat
+
+import (
+	"testing"
+
+	"gopkg.in/mgutz/dat.v1/mysql"
+	"gopkg.in/stretchr/testify.v1/assert"
+)
+
+func TestSelectWith(t *testing.T) {
+	recent := Select("id", "user_id").From("posts").Where("created_at > $1", 10)
+	sql, args := Select("p.name", "r.id").
+		With("recent", recent).
+		With("named", "SELECT id, name FROM people WHERE name = $1", "Mario").
+		From("named p INNER JOIN recent r ON r.user_id = p.id").
+		Where("r.id > $1", 2).
+		Paginate(2, 10).
+		ToSQL()
+
+	assert.Equal(t, stripWS(`
+		WITH recent AS (SELECT id, user_id FROM posts WHERE (created_at > $1)),
+			named AS (SELECT id, name FROM people WHERE name = $2)
+		SELECT p.name, r.id
+		FROM named p INNER JOIN recent r ON r.user_id = p.id
+		WHERE (r.id > $3)
+		LIMIT 10 OFFSET 10`), stripWS(sql))
+	assert.Equal(t, []interface{}{10, "Mario", 2}, args)
+}
+
+func TestSelectWithRecursive(t *testing.T) {
+	sql, args := Select("id", "parent_id").
+		WithRecursive("tree(id, parent_id)", `
+			SELECT id, parent_id FROM nodes WHERE id = $1
+			UNION ALL
+			SELECT n.id, n.parent_id FROM nodes n INNER JOIN tree t ON n.parent_id = t.id`, 1).
+		From("tree").
+		Where(Eq{"parent_id": 1}).
+		ToSQL()
+
+	assert.Equal(t, stripWS(`
+		WITH RECURSIVE tree(id, parent_id) AS (
+			SELECT id, parent_id FROM nodes WHERE id = $1
+			UNION ALL
+			SELECT n.id, n.parent_id FROM nodes n INNER JOIN tree t ON n.parent_id = t.id)
+		SELECT id, parent_id FROM tree WHERE ("parent_id" = $2)`), stripWS(sql))
+	assert.Equal(t, []interface{}{1, 1}, args)
+}
+
+func TestUpdateDeleteInsertWith(t *testing.T) {
+	stale := Select("id").From("sessions").Where("expires_at < $1", 5)
+
+	sql, args := Update("sessions").
+		With("stale", stale).
+		Set("active", false).
+		Where("id IN (SELECT id FROM stale)").
+		ToSQL()
+	assert.Equal(t, `WITH stale AS (SELECT id FROM sessions WHERE (expires_at < $1)) UPDATE "sessions" SET "active" = $2 WHERE (id IN (SELECT id FROM stale))`, sql)
+	assert.Equal(t, []interface{}{5, false}, args)
+
+	sql, args = DeleteFrom("sessions").
+		With("stale", stale).
+		Where("id IN (SELECT id FROM stale) AND user_id = $1", 3).
+		ToSQL()
+	assert.Equal(t, `WITH stale AS (SELECT id FROM sessions WHERE (expires_at < $1)) DELETE FROM sessions WHERE (id IN (SELECT id FROM stale) AND user_id = $2)`, sql)
+	assert.Equal(t, []interface{}{5, 3}, args)
+
+	sql, args = InsertInto("archive").
+		With("stale", stale).
+		Columns("session_id", "note").
+		FromSelect(Select("id", "note").From("stale").Where("id > $1", 1)).
+		ToSQL()
+	assert.Equal(t, `WITH stale AS (SELECT id FROM sessions WHERE (expires_at < $1)) INSERT INTO archive ("session_id","note") SELECT id, note FROM stale WHERE (id > $2)`, sql)
+	assert.Equal(t, []interface{}{5, 1}, args)
+
+	sql, args = InsertInto("archive").
+		With("stale", stale).
+		Columns("session_id").
+		Values(9).
+		ToSQL()
+	assert.Equal(t, `WITH stale AS (SELECT id FROM sessions WHERE (expires_at < $1)) INSERT INTO archive ("session_id") VALUES ($2)`, sql)
+	assert.Equal(t, []interface{}{5, 9}, args)
+}
+
+func TestWithDialect(t *testing.T) {
+	recent := Select("id").From("posts").Where("user_id = $1 OR author_id = $1", 7)
+	sql, args := Select("id").
+		With("recent", recent).
+		From("recent").
+		Where("id > $1", 2).
+		SetDialect(mysql.New()).
+		ToSQL()
+
+	assert.Equal(t, "WITH recent AS (SELECT id FROM posts WHERE (user_id = ? OR author_id = ?)) SELECT id FROM recent WHERE (id > ?)", sql)
+	assert.Equal(t, []interface{}{7, 7, 2}, args)
+	assert.Nil(t, recent.dialect)
+}
+
+func TestWithInvalid(t *testing.T) {
+	assert.Panics(t, func() {
+		Select("id").With("", "SELECT 1")
+	})
+	assert.Panics(t, func() {
+		Select("id").With("a", 1)
+	})
+}